@@ -0,0 +1,111 @@
+package autenticacao
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Modos de autenticação das chamadas ao Agriwin.
+const (
+	ModeNone   = "none"
+	ModeBearer = "bearer" // Token fixo no header Authorization
+	ModeOAuth2 = "oauth2" // Client credentials em um endpoint de token
+	ModeHMAC   = "hmac"   // Assinatura de cada requisição com um segredo compartilhado
+)
+
+// Options configura a autenticação. Só os campos do modo escolhido são usados.
+type Options struct {
+	Mode string
+
+	// bearer
+	Token string
+
+	// oauth2
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+
+	// hmac
+	HMACSecret string
+	HMACKeyID  string // Opcional: identifica o segredo quando o Agriwin aceita mais de um
+}
+
+// Authenticator adiciona as credenciais a uma requisição ao Agriwin. Deve ser chamado depois
+// que corpo e headers estão prontos, pois a assinatura HMAC os cobre.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// invalidator é implementado pelos Authenticators que reaproveitam uma credencial obtida do
+// Agriwin, como o token OAuth2, e podem descartá-la quando ela é recusada.
+type invalidator interface {
+	Invalidate(req *http.Request)
+}
+
+// Do autentica e envia a requisição. Se o Agriwin responder 401 a uma credencial que pode ser
+// renovada, ela é descartada e a requisição é repetida uma vez com uma nova. auth pode ser nil.
+func Do(client *http.Client, auth Authenticator, req *http.Request) (*http.Response, error) {
+	if auth == nil {
+		return client.Do(req)
+	}
+	if err := auth.Authenticate(req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
+	resp, err := client.Do(req)
+	inv, renewable := auth.(invalidator)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !renewable {
+		return resp, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	} else if req.Body != nil && req.Body != http.NoBody {
+		// Sem como reenviar o corpo, fica o 401 original
+		return resp, nil
+	}
+	resp.Body.Close()
+	inv.Invalidate(req)
+	if err := auth.Authenticate(retry); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
+	return client.Do(retry)
+}
+
+// New cria o Authenticator do modo informado. "none" (ou vazio) desativa a autenticação
+// e retorna nil.
+func New(opts Options) (Authenticator, error) {
+	switch strings.ToLower(strings.TrimSpace(opts.Mode)) {
+	case "", ModeNone:
+		return nil, nil
+	case ModeBearer:
+		if opts.Token == "" {
+			return nil, fmt.Errorf("bearer auth needs a token")
+		}
+		return bearer{token: opts.Token}, nil
+	case ModeOAuth2:
+		if opts.TokenURL == "" || opts.ClientID == "" || opts.ClientSecret == "" {
+			return nil, fmt.Errorf("oauth2 auth needs a token URL, client ID and client secret")
+		}
+		return newClientCredentials(opts), nil
+	case ModeHMAC:
+		if opts.HMACSecret == "" {
+			return nil, fmt.Errorf("hmac auth needs a secret")
+		}
+		return &hmacSigner{secret: []byte(opts.HMACSecret), keyID: opts.HMACKeyID}, nil
+	}
+	return nil, fmt.Errorf("unknown auth mode %q (expected none, bearer, oauth2 or hmac)", opts.Mode)
+}
+
+type bearer struct {
+	token string
+}
+
+func (b bearer) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	return nil
+}
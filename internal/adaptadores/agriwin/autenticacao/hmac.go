@@ -0,0 +1,57 @@
+package autenticacao
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers da assinatura HMAC.
+const (
+	HeaderTimestamp = "X-Agriwin-Timestamp"
+	HeaderSignature = "X-Agriwin-Signature"
+	HeaderKeyID     = "X-Agriwin-Key-Id"
+)
+
+// hmacSigner assina cada requisição com HMAC-SHA256 sobre
+//
+//	MÉTODO \n caminho?query \n timestamp \n sha256(corpo) em hex
+//
+// O timestamp (segundos Unix) vai no header, para o Agriwin recusar requisições antigas
+// ou repetidas; a assinatura vai como "sha256=<hex>".
+type hmacSigner struct {
+	secret []byte
+	keyID  string
+}
+
+func (s *hmacSigner) Authenticate(req *http.Request) error {
+	bodyHash := sha256.New()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+		defer body.Close()
+		if _, err := io.Copy(bodyHash, body); err != nil {
+			return fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+	} else if req.Body != nil && req.Body != http.NoBody {
+		return fmt.Errorf("cannot sign a request whose body cannot be read again")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", req.Method, req.URL.RequestURI(), timestamp, hex.EncodeToString(bodyHash.Sum(nil)))
+
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	if s.keyID != "" {
+		req.Header.Set(HeaderKeyID, s.keyID)
+	}
+	return nil
+}
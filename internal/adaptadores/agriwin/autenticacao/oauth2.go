@@ -0,0 +1,112 @@
+package autenticacao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// refreshMargin antecipa a renovação do token para que ele não expire no meio de um envio.
+	// Para tokens curtos, a margem é no máximo metade da validade.
+	refreshMargin = 30 * time.Second
+	// defaultTokenLifetime é a validade assumida quando o endpoint não informa expires_in. Se o
+	// Agriwin recusar o token antes disso, o 401 o invalida (veja Do).
+	defaultTokenLifetime = 5 * time.Minute
+)
+
+// clientCredentials obtém um token OAuth2 pelo fluxo client credentials e o reaproveita
+// até perto de expirar. Os envios em paralelo esperam a mesma renovação.
+type clientCredentials struct {
+	opts       Options
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+func newClientCredentials(opts Options) *clientCredentials {
+	return &clientCredentials{
+		opts: opts,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (c *clientCredentials) Authenticate(req *http.Request) error {
+	token, err := c.currentToken(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *clientCredentials) currentToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.refreshAt) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if c.opts.Scope != "" {
+		form.Set("scope", c.opts.Scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.opts.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create agriwin token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.opts.ClientID), url.QueryEscape(c.opts.ClientSecret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get agriwin access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("agriwin token endpoint responded with status: %s", resp.Status)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode agriwin token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("agriwin token endpoint returned no access token")
+	}
+	if body.TokenType != "" && !strings.EqualFold(body.TokenType, "bearer") {
+		return "", fmt.Errorf("agriwin token endpoint returned unsupported token type %q", body.TokenType)
+	}
+
+	lifetime := time.Duration(body.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	c.token = body.AccessToken
+	c.refreshAt = time.Now().Add(lifetime - min(refreshMargin, lifetime/2))
+	return c.token, nil
+}
+
+// Invalidate descarta o token recusado em req, para que a próxima chamada busque outro. Um
+// token já renovado por outro envio em paralelo é mantido.
+func (c *clientCredentials) Invalidate(req *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && req.Header.Get("Authorization") == "Bearer "+c.token {
+		c.token = ""
+	}
+}
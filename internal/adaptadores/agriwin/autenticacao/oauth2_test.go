@@ -0,0 +1,125 @@
+package autenticacao
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// tokenServer emite tokens numerados ("token-1", "token-2"...) e, em /api, aceita apenas os
+// tokens em valid, devolvendo o corpo recebido.
+func tokenServer(t *testing.T, expiresIn string, valid func(token string) bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var issued atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			n := issued.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer"%s}`, n, expiresIn)
+		case "/api":
+			if !valid(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			io.Copy(w, r.Body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func newTestClientCredentials(server *httptest.Server) *clientCredentials {
+	return newClientCredentials(Options{Mode: ModeOAuth2, TokenURL: server.URL + "/token", ClientID: "vestro", ClientSecret: "secret"})
+}
+
+func TestTokenWithoutExpiresInIsReused(t *testing.T) {
+	server, issued := tokenServer(t, "", func(string) bool { return true })
+	auth := newTestClientCredentials(server)
+
+	for range 3 {
+		req, _ := http.NewRequest("GET", server.URL+"/api", nil)
+		if err := auth.Authenticate(req); err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer token-1" {
+			t.Errorf("Authorization = %q, want the first token", got)
+		}
+	}
+	if n := issued.Load(); n != 1 {
+		t.Errorf("fetched %d tokens, want 1", n)
+	}
+}
+
+func TestShortTokenIsReused(t *testing.T) {
+	server, issued := tokenServer(t, `,"expires_in":20`, func(string) bool { return true })
+	auth := newTestClientCredentials(server)
+
+	for range 2 {
+		req, _ := http.NewRequest("GET", server.URL+"/api", nil)
+		if err := auth.Authenticate(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := issued.Load(); n != 1 {
+		t.Errorf("fetched %d tokens, want 1", n)
+	}
+}
+
+func TestDoRetriesOnceWithANewToken(t *testing.T) {
+	// O primeiro token é revogado no Agriwin antes de expirar
+	server, issued := tokenServer(t, `,"expires_in":3600`, func(token string) bool { return token != "token-1" })
+	auth := newTestClientCredentials(server)
+
+	req, _ := http.NewRequest("POST", server.URL+"/api", strings.NewReader(`{"ok":true}`))
+	resp, err := Do(server.Client(), auth, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `{"ok":true}` {
+		t.Errorf("response = %s %q, want 200 with the request body sent again", resp.Status, body)
+	}
+	if n := issued.Load(); n != 2 {
+		t.Errorf("fetched %d tokens, want 2", n)
+	}
+}
+
+func TestDoReturnsASecondUnauthorized(t *testing.T) {
+	server, issued := tokenServer(t, `,"expires_in":3600`, func(string) bool { return false })
+	auth := newTestClientCredentials(server)
+
+	req, _ := http.NewRequest("GET", server.URL+"/api", nil)
+	resp, err := Do(server.Client(), auth, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || issued.Load() != 2 {
+		t.Errorf("got %s after %d tokens, want 401 after retrying once", resp.Status, issued.Load())
+	}
+}
+
+func TestDoDoesNotRetryFixedCredentials(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := Do(server.Client(), bearer{token: "fixed"}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
@@ -0,0 +1,53 @@
+package confirmacao
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"vestro/internal/adaptadores/agriwin/autenticacao"
+	"vestro/internal/dto"
+)
+
+type acknowledger struct {
+	ackURL     string
+	auth       autenticacao.Authenticator // nil = sem autenticação
+	httpClient *http.Client
+}
+
+func New(ackURL string, auth autenticacao.Authenticator) *acknowledger {
+	return &acknowledger{
+		ackURL: ackURL,
+		auth:   auth,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (a *acknowledger) Acknowledge(ctx context.Context, ack dto.SyncAcknowledgement) error {
+	jsonData, err := json.Marshal(ack)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync acknowledgement: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.ackURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request for agriwin acknowledgement: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := autenticacao.Do(a.httpClient, a.auth, req)
+	if err != nil {
+		return fmt.Errorf("failed to send acknowledgement to agriwin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("agriwin acknowledgement endpoint responded with status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -6,17 +6,20 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+	"vestro/internal/adaptadores/agriwin/autenticacao"
 	"vestro/internal/dto"
 )
 
 type userProvider struct {
 	usersURL   string
+	auth       autenticacao.Authenticator // nil = sem autenticação
 	httpClient *http.Client
 }
 
-func New(usersURL string) *userProvider {
+func New(usersURL string, auth autenticacao.Authenticator) *userProvider {
 	return &userProvider{
 		usersURL: usersURL,
+		auth:     auth,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -28,10 +31,8 @@ func (p *userProvider) GetUsersToIntegrate(ctx context.Context) ([]dto.UserToInt
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request for agriwin users: %w", err)
 	}
-	// Se precisar de autenticação, adicione o header aqui
-	// req.Header.Set("Authorization", "Bearer your_token")
 
-	resp, err := p.httpClient.Do(req)
+	resp, err := autenticacao.Do(p.httpClient, p.auth, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users from agriwin: %w", err)
 	}
@@ -0,0 +1,78 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"vestro/internal/dto"
+)
+
+// fileStore guarda os checkpoints em um único arquivo JSON. A escrita é feita em um
+// arquivo temporário seguido de rename, para não corromper o arquivo se o job cair no meio.
+type fileStore struct {
+	path string
+
+	mu          sync.Mutex
+	checkpoints map[string]dto.Checkpoint
+}
+
+func NewFileStore(path string) (*fileStore, error) {
+	store := &fileStore{path: path, checkpoints: make(map[string]dto.Checkpoint)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &store.checkpoints); err != nil {
+			return nil, fmt.Errorf("failed to decode checkpoint file: %w", err)
+		}
+	}
+	return store, nil
+}
+
+func (f *fileStore) Load(ctx context.Context, produtorID int, entity string) (dto.Checkpoint, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp, ok := f.checkpoints[key(produtorID, entity)]
+	return cp, ok, nil
+}
+
+func (f *fileStore) Save(ctx context.Context, cp dto.Checkpoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checkpoints[key(cp.ProdutorID, cp.Entity)] = cp
+
+	data, err := json.MarshalIndent(f.checkpoints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoints: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint file: %w", err)
+	}
+	return nil
+}
+
+func (f *fileStore) Close() error {
+	return nil
+}
+
+// key identifica o checkpoint de um produtor para uma entidade.
+func key(produtorID int, entity string) string {
+	return strconv.Itoa(produtorID) + "/" + entity
+}
@@ -0,0 +1,69 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"vestro/internal/dto"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var checkpointsBucket = []byte("checkpoints")
+
+// boltStore guarda os checkpoints em um arquivo BoltDB, um registro JSON por chave.
+type boltStore struct {
+	db *bolt.DB
+}
+
+func NewBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt checkpoint store: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(checkpointsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create checkpoint bucket: %w", err)
+	}
+	return &boltStore{db: db}, nil
+}
+
+func (b *boltStore) Load(ctx context.Context, produtorID int, entity string) (dto.Checkpoint, bool, error) {
+	var cp dto.Checkpoint
+	var found bool
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(checkpointsBucket).Get([]byte(key(produtorID, entity)))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &cp)
+	})
+	if err != nil {
+		return dto.Checkpoint{}, false, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	return cp, found, nil
+}
+
+func (b *boltStore) Save(ctx context.Context, cp dto.Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(checkpointsBucket).Put([]byte(key(cp.ProdutorID, cp.Entity)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (b *boltStore) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,51 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"vestro/internal/aplicacao/portas"
+	"vestro/internal/dto"
+)
+
+// Open cria o CheckpointStore do tipo informado: "file", "bolt" ou "sqlite".
+// "none" (ou vazio) desativa os checkpoints e retorna nil.
+func Open(kind, path string) (portas.CheckpointStore, error) {
+	var (
+		store portas.CheckpointStore
+		err   error
+	)
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "file":
+		store, err = NewFileStore(path)
+	case "bolt":
+		store, err = NewBoltStore(path)
+	case "sqlite":
+		store, err = NewSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("unknown checkpoint store %q (expected file, bolt, sqlite or none)", kind)
+	}
+	if err != nil {
+		// Evita devolver uma interface não-nil envolvendo um ponteiro nil
+		return nil, err
+	}
+	return store, nil
+}
+
+// ReadOnly envolve o store para que os checkpoints sejam lidos mas nunca gravados,
+// como no dry-run, em que nada foi de fato entregue ao Agriwin.
+func ReadOnly(store portas.CheckpointStore) portas.CheckpointStore {
+	if store == nil {
+		return nil
+	}
+	return readOnlyStore{store}
+}
+
+type readOnlyStore struct {
+	portas.CheckpointStore
+}
+
+func (readOnlyStore) Save(ctx context.Context, cp dto.Checkpoint) error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vestro/internal/dto"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteStore guarda os checkpoints em uma tabela SQLite. O driver usa cgo: o binário
+// precisa ser compilado com CGO_ENABLED=1 (e um compilador C) para usar este store.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// errSQLiteUnavailable é devolvido ao abrir o store em um binário compilado sem cgo.
+var errSQLiteUnavailable = errors.New("the sqlite checkpoint store needs a binary built with CGO_ENABLED=1; rebuild with cgo or use CHECKPOINT_STORE=file or bolt")
+
+func NewSQLiteStore(path string) (*sqliteStore, error) {
+	if !sqliteAvailable {
+		return nil, errSQLiteUnavailable
+	}
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite checkpoint store: %w", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS checkpoints (
+		produtor_id    INTEGER NOT NULL,
+		entity         TEXT    NOT NULL,
+		last_timestamp TEXT    NOT NULL,
+		last_id        INTEGER NOT NULL,
+		updated_at     TEXT    NOT NULL,
+		seen_ids       TEXT    NOT NULL DEFAULT '{}',
+		hash           TEXT    NOT NULL DEFAULT '',
+		PRIMARY KEY (produtor_id, entity)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create checkpoints table: %w", err)
+	}
+	// Bancos criados antes da sobreposição de janela não têm a coluna seen_ids, e os criados
+	// antes do acompanhamento dos dados mestres não têm a coluna hash
+	for _, column := range []string{
+		`seen_ids TEXT NOT NULL DEFAULT '{}'`,
+		`hash TEXT NOT NULL DEFAULT ''`,
+	} {
+		if _, err := db.Exec(`ALTER TABLE checkpoints ADD COLUMN ` + column); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate checkpoints table: %w", err)
+		}
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) Load(ctx context.Context, produtorID int, entity string) (dto.Checkpoint, bool, error) {
+	var lastTimestamp, updatedAt, seenIDs string
+	cp := dto.Checkpoint{ProdutorID: produtorID, Entity: entity}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT last_timestamp, last_id, updated_at, seen_ids, hash FROM checkpoints WHERE produtor_id = ? AND entity = ?`,
+		produtorID, entity,
+	).Scan(&lastTimestamp, &cp.LastID, &updatedAt, &seenIDs, &cp.Hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.Checkpoint{}, false, nil
+	}
+	if err != nil {
+		return dto.Checkpoint{}, false, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	if cp.LastTimestamp, err = time.Parse(time.RFC3339Nano, lastTimestamp); err != nil {
+		return dto.Checkpoint{}, false, fmt.Errorf("invalid checkpoint timestamp %q: %w", lastTimestamp, err)
+	}
+	cp.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	if err := json.Unmarshal([]byte(seenIDs), &cp.SeenIDs); err != nil {
+		return dto.Checkpoint{}, false, fmt.Errorf("invalid checkpoint seen ids: %w", err)
+	}
+	return cp, true, nil
+}
+
+func (s *sqliteStore) Save(ctx context.Context, cp dto.Checkpoint) error {
+	seenIDs, err := json.Marshal(cp.SeenIDs)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint seen ids: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO checkpoints (produtor_id, entity, last_timestamp, last_id, updated_at, seen_ids, hash)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (produtor_id, entity) DO UPDATE SET
+		   last_timestamp = excluded.last_timestamp,
+		   last_id        = excluded.last_id,
+		   updated_at     = excluded.updated_at,
+		   seen_ids       = excluded.seen_ids,
+		   hash           = excluded.hash`,
+		cp.ProdutorID, cp.Entity,
+		cp.LastTimestamp.UTC().Format(time.RFC3339Nano), cp.LastID,
+		cp.UpdatedAt.UTC().Format(time.RFC3339Nano), string(seenIDs), cp.Hash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
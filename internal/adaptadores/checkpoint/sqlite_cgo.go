@@ -0,0 +1,6 @@
+//go:build cgo
+
+package checkpoint
+
+// sqliteAvailable indica se o driver SQLite (go-sqlite3, que usa cgo) está no binário.
+const sqliteAvailable = true
@@ -0,0 +1,7 @@
+//go:build !cgo
+
+package checkpoint
+
+// sqliteAvailable indica se o driver SQLite (go-sqlite3, que usa cgo) está no binário. Sem
+// cgo, o go-sqlite3 compila só um stub que falha na primeira consulta.
+const sqliteAvailable = false
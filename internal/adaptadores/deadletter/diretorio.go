@@ -0,0 +1,83 @@
+package deadletter
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"vestro/internal/dto"
+)
+
+// dirQueue guarda cada envio que falhou em um arquivo JSON próprio dentro de dir.
+// Assim como no checkpoint, a escrita é feita em um arquivo temporário seguido de rename.
+type dirQueue struct {
+	dir string
+}
+
+func New(dir string) (*dirQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter directory: %w", err)
+	}
+	return &dirQueue{dir: dir}, nil
+}
+
+func (q *dirQueue) Put(ctx context.Context, entry dto.DeadLetter) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter %s: %w", entry.ID, err)
+	}
+	path := q.path(entry.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write dead letter %s: %w", entry.ID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace dead letter %s: %w", entry.ID, err)
+	}
+	return nil
+}
+
+// List lê todas as entradas do diretório. Arquivos corrompidos são ignorados (e logados)
+// para não travar o reenvio dos demais.
+func (q *dirQueue) List(ctx context.Context) ([]dto.DeadLetter, error) {
+	files, err := filepath.Glob(filepath.Join(q.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	entries := make([]dto.DeadLetter, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dead letter %s: %w", file, err)
+		}
+		var entry dto.DeadLetter
+		if err := json.Unmarshal(data, &entry); err != nil {
+			log.Printf("Warning: skipping unreadable dead letter %s: %v", file, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b dto.DeadLetter) int {
+		return cmp.Or(a.FailedAt.Compare(b.FailedAt), strings.Compare(a.ID, b.ID))
+	})
+	return entries, nil
+}
+
+func (q *dirQueue) Delete(ctx context.Context, id string) error {
+	if err := os.Remove(q.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete dead letter %s: %w", id, err)
+	}
+	return nil
+}
+
+func (q *dirQueue) path(id string) string {
+	return filepath.Join(q.dir, id+".json")
+}
@@ -0,0 +1,96 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"vestro/internal/dto"
+)
+
+// Formatos de saída do dry-run.
+const (
+	FormatJSON   = "json"   // JSON indentado, um arquivo por payload quando a saída é um diretório
+	FormatNDJSON = "ndjson" // Um payload por linha, todos no mesmo arquivo (payloads.ndjson)
+)
+
+// notifier substitui o envio ao Grails, gravando os payloads em um diretório ou na saída padrão.
+type notifier struct {
+	dir    string // Vazio = saída padrão
+	format string
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// New cria o notifier do dry-run. output é um diretório ou "-" para a saída padrão, que
+// então fica só com os payloads (o main manda o relatório para a saída de erro, como os logs).
+func New(output, format string) (*notifier, error) {
+	if format != FormatJSON && format != FormatNDJSON {
+		return nil, fmt.Errorf("unknown dry-run format %q (expected json or ndjson)", format)
+	}
+	n := &notifier{format: format, out: os.Stdout}
+	if output == "" || output == "-" {
+		return n, nil
+	}
+	if err := os.MkdirAll(output, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create dry-run directory: %w", err)
+	}
+	n.dir = output
+	return n, nil
+}
+
+func (n *notifier) Send(ctx context.Context, payload dto.IntegrationPayload) error {
+	var (
+		data []byte
+		err  error
+	)
+	if n.format == FormatJSON {
+		data, err = json.MarshalIndent(payload, "", "  ")
+	} else {
+		data, err = json.Marshal(payload)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal dry-run payload: %w", err)
+	}
+	data = append(data, '\n')
+
+	// Vários produtores enviam ao mesmo tempo; a escrita é serializada para não misturar payloads
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch {
+	case n.dir == "":
+		_, err = n.out.Write(data)
+	case n.format == FormatJSON:
+		err = os.WriteFile(filepath.Join(n.dir, fileName(payload)), data, 0o644)
+	default:
+		err = appendFile(filepath.Join(n.dir, "payloads.ndjson"), data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write dry-run payload: %w", err)
+	}
+	return nil
+}
+
+// fileName identifica o arquivo do payload pelo produtor e pela posição no conjunto de envios.
+func fileName(payload dto.IntegrationPayload) string {
+	if payload.Batch == nil {
+		return fmt.Sprintf("produtor-%d.json", payload.ProdutorID)
+	}
+	return fmt.Sprintf("%s-%03d.json", payload.Batch.DeliveryID, payload.Batch.Sequence)
+}
+
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,45 @@
+package hashes
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"vestro/internal/aplicacao/portas"
+	"vestro/internal/dto"
+)
+
+// checkpointStore grava os hashes no CheckpointStore, para que sobrevivam entre as
+// execuções avulsas. Cada lista é um checkpoint próprio do produtor.
+type checkpointStore struct {
+	checkpoints portas.CheckpointStore
+}
+
+// NewCheckpointStore retorna nil quando não há CheckpointStore; sem hashes, as listas
+// são enviadas em toda execução.
+func NewCheckpointStore(checkpoints portas.CheckpointStore) portas.MasterDataHashes {
+	if checkpoints == nil {
+		return nil
+	}
+	return &checkpointStore{checkpoints: checkpoints}
+}
+
+func (s *checkpointStore) Load(ctx context.Context, key dto.MasterDataKey) (string, bool, error) {
+	cp, ok, err := s.checkpoints.Load(ctx, key.ProdutorID, checkpointEntity(key))
+	if err != nil || !ok {
+		return "", false, err
+	}
+	return cp.Hash, cp.Hash != "", nil
+}
+
+func (s *checkpointStore) Save(ctx context.Context, key dto.MasterDataKey, hash string) error {
+	return s.checkpoints.Save(ctx, dto.Checkpoint{
+		ProdutorID: key.ProdutorID,
+		Entity:     checkpointEntity(key),
+		Hash:       hash,
+		UpdatedAt:  time.Now(),
+	})
+}
+
+func checkpointEntity(key dto.MasterDataKey) string {
+	return fmt.Sprintf("hash %s %s", key.Entity, key.Account)
+}
@@ -0,0 +1,32 @@
+package hashes
+
+import (
+	"context"
+	"sync"
+	"vestro/internal/dto"
+)
+
+// memoryStore guarda os hashes apenas enquanto o processo vive, como no modo serve: depois
+// de reiniciar, a primeira execução envia todas as listas de novo.
+type memoryStore struct {
+	mu     sync.Mutex
+	hashes map[dto.MasterDataKey]string
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{hashes: make(map[dto.MasterDataKey]string)}
+}
+
+func (s *memoryStore) Load(ctx context.Context, key dto.MasterDataKey) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash, ok := s.hashes[key]
+	return hash, ok, nil
+}
+
+func (s *memoryStore) Save(ctx context.Context, key dto.MasterDataKey, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashes[key] = hash
+	return nil
+}
@@ -0,0 +1,60 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"vestro/internal/dto"
+)
+
+// dirStore guarda o snapshot de cada lista de cada produtor em um arquivo JSON próprio
+// dentro de dir. Assim como no checkpoint, a escrita é feita em um arquivo temporário
+// seguido de rename.
+type dirStore struct {
+	dir string
+}
+
+func New(dir string) (*dirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	return &dirStore{dir: dir}, nil
+}
+
+func (s *dirStore) Load(ctx context.Context, produtorID int, entity string) (dto.Snapshot, bool, error) {
+	data, err := os.ReadFile(s.path(produtorID, entity))
+	if errors.Is(err, os.ErrNotExist) {
+		return dto.Snapshot{}, false, nil
+	}
+	if err != nil {
+		return dto.Snapshot{}, false, fmt.Errorf("failed to read %s snapshot: %w", entity, err)
+	}
+	var snap dto.Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return dto.Snapshot{}, false, fmt.Errorf("failed to decode %s snapshot: %w", entity, err)
+	}
+	return snap, true, nil
+}
+
+func (s *dirStore) Save(ctx context.Context, snap dto.Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s snapshot: %w", snap.Entity, err)
+	}
+	path := s.path(snap.ProdutorID, snap.Entity)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s snapshot: %w", snap.Entity, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace %s snapshot: %w", snap.Entity, err)
+	}
+	return nil
+}
+
+func (s *dirStore) path(produtorID int, entity string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%d-%s.json", produtorID, entity))
+}
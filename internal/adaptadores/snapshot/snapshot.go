@@ -0,0 +1,24 @@
+package snapshot
+
+import (
+	"context"
+	"vestro/internal/aplicacao/portas"
+	"vestro/internal/dto"
+)
+
+// ReadOnly envolve o store para que os snapshots sejam lidos mas nunca gravados,
+// como no dry-run, em que nada foi de fato entregue ao Agriwin.
+func ReadOnly(store portas.SnapshotStore) portas.SnapshotStore {
+	if store == nil {
+		return nil
+	}
+	return readOnlyStore{store}
+}
+
+type readOnlyStore struct {
+	portas.SnapshotStore
+}
+
+func (readOnlyStore) Save(ctx context.Context, snap dto.Snapshot) error {
+	return nil
+}
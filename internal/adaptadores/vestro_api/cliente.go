@@ -5,48 +5,123 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
 	"net/url"
-	"strconv"
+	"sync"
 	"time"
+	"vestro/internal/aplicacao/portas"
 	"vestro/internal/dto"
 )
 
+// Options agrupa as configurações opcionais do cliente Vestro.
+type Options struct {
+	Retry RetryPolicy
+
+	// MaxInFlight limita as requisições simultâneas à Vestro somando todos os produtores. 0 = sem limite.
+	MaxInFlight int
+
+	// PageConcurrency é quantas páginas de um mesmo endpoint são buscadas em paralelo
+	// quando a Vestro informa o total de registros. <= 1 busca uma página por vez.
+	PageConcurrency int
+
+	// TokenTTL é a validade assumida do token quando o JWT não traz a claim "exp". 0 = desconhecida.
+	TokenTTL time.Duration
+	// RefreshMargin é a antecedência com que o token é renovado antes de expirar.
+	RefreshMargin time.Duration
+	// RefreshPath é a rota da Vestro que troca o Session por um novo Access.
+	// Vazio = renova fazendo um novo login com as credenciais guardadas.
+	RefreshPath string
+
+	// EndDateParam é o parâmetro da Vestro que recebe o fim da janela dos dados transacionais.
+	// Vazio = não é enviado. Em qualquer caso, registros depois do fim da janela são descartados
+	// no cliente, então a janela é respeitada mesmo que a Vestro ignore o parâmetro.
+	EndDateParam string
+}
+
 type apiClient struct {
 	baseURL    string
 	httpClient *http.Client
+	retrier    *retrier
+	limiter    *requestLimiter
+	opts       Options
+
+	// Sessões já autenticadas, por login, para reaproveitar o token entre chamadas.
+	mu       sync.Mutex
+	sessions map[string]*session
 }
 
-func New(baseURL string) *apiClient {
+func New(baseURL string, opts Options) *apiClient {
 	return &apiClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 45 * time.Second,
 		},
+		retrier:  newRetrier(opts.Retry),
+		limiter:  newRequestLimiter(opts.MaxInFlight),
+		opts:     opts,
+		sessions: make(map[string]*session),
 	}
 }
 
-func (c *apiClient) Authenticate(ctx context.Context, login, password string) (string, error) {
+// ResetRetryBudget zera o orçamento de retries. Um processo que roda vários jobs, como o
+// modo serve, chama no início de cada um, para que o orçamento valha por execução.
+func (c *apiClient) ResetRetryBudget() {
+	c.retrier.resetBudget()
+}
+
+// Authenticate faz o login na Vestro e devolve uma sessão que guarda o token
+// e as credenciais, renovando o acesso automaticamente quando ele expira.
+// Se o mesmo login já tiver uma sessão ativa, o token em cache é reaproveitado.
+func (c *apiClient) Authenticate(ctx context.Context, login, password string) (portas.VestroSession, error) {
+	c.mu.Lock()
+	sess, ok := c.sessions[login]
+	if !ok || sess.password != password {
+		sess = &session{client: c, login: login, password: password}
+		c.sessions[login] = sess
+	}
+	c.mu.Unlock()
+
+	if _, err := sess.validToken(ctx); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// send executa uma requisição respeitando o limite global de requisições simultâneas.
+func (c *apiClient) send(req *http.Request) (*http.Response, error) {
+	return c.limiter.do(c.httpClient, req)
+}
+
+// requestToken executa o POST /sessions e retorna os tokens emitidos pela Vestro.
+func (c *apiClient) requestToken(ctx context.Context, login, password string) (dto.AuthResponse, error) {
 	formData := url.Values{}
 	formData.Set("login", login)
 	formData.Set("password", password)
+	return c.postSession(ctx, "/sessions", formData)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/sessions", bytes.NewBufferString(formData.Encode()))
+// refreshToken troca o token de sessão por um novo token de acesso, sem reenviar a senha.
+func (c *apiClient) refreshToken(ctx context.Context, sessionToken string) (dto.AuthResponse, error) {
+	formData := url.Values{}
+	formData.Set("session", sessionToken)
+	return c.postSession(ctx, c.opts.RefreshPath, formData)
+}
+
+func (c *apiClient) postSession(ctx context.Context, path string, formData url.Values) (dto.AuthResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBufferString(formData.Encode()))
 	if err != nil {
-		return "", fmt.Errorf("failed to create auth request: %w", err)
+		return dto.AuthResponse{}, fmt.Errorf("failed to create auth request: %w", err)
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute auth request: %w", err)
+		return dto.AuthResponse{}, fmt.Errorf("failed to execute auth request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("auth request failed with status: %s", resp.Status)
+		return dto.AuthResponse{}, fmt.Errorf("auth request failed with status: %s", resp.Status)
 	}
 
 	var wrapper struct {
@@ -55,119 +130,12 @@ func (c *apiClient) Authenticate(ctx context.Context, login, password string) (s
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
-		return "", fmt.Errorf("failed to decode auth response: %w", err)
+		return dto.AuthResponse{}, fmt.Errorf("failed to decode auth response: %w", err)
 	}
 
 	if !wrapper.Success {
-		return "", fmt.Errorf("authentication failed on API")
+		return dto.AuthResponse{}, fmt.Errorf("authentication failed on API")
 	}
 
-	return wrapper.Data.Access, nil
-}
-
-// As funções abaixo agora chamam a *função* genérica fetchAndAggregate,
-// passando as dependências do apiClient.
-func (c *apiClient) GetSupplies(ctx context.Context, token string, since time.Time, userIdentifier string) ([]dto.Supply, error) {
-	// A propriedade de filtro 'driver' é um palpite. Pode ser 'employee' ou outra.
-	return fetchAndAggregate[dto.Supply](ctx, c.httpClient, c.baseURL, token, "/supplies", since, "driver", userIdentifier)
-}
-
-func (c *apiClient) GetProductSales(ctx context.Context, token string, since time.Time, userIdentifier string) ([]dto.ProductSale, error) {
-	return fetchAndAggregate[dto.ProductSale](ctx, c.httpClient, c.baseURL, token, "/product/sales", since, "driver", userIdentifier)
-}
-
-func (c *apiClient) GetProducts(ctx context.Context, token string) ([]dto.Product, error) {
-	return fetchAndAggregate[dto.Product](ctx, c.httpClient, c.baseURL, token, "/products", time.Time{}, "", "")
-}
-
-func (c *apiClient) GetFuelTypes(ctx context.Context, token string) ([]dto.FuelType, error) {
-	return fetchAndAggregate[dto.FuelType](ctx, c.httpClient, c.baseURL, token, "/fuel/types", time.Time{}, "", "")
-}
-
-func (c *apiClient) GetVehicles(ctx context.Context, token string) ([]dto.Vehicle, error) {
-	return fetchAndAggregate[dto.Vehicle](ctx, c.httpClient, c.baseURL, token, "/vehicles", time.Time{}, "", "")
-}
-
-func (c *apiClient) GetDrivers(ctx context.Context, token string) ([]dto.Driver, error) {
-	return fetchAndAggregate[dto.Driver](ctx, c.httpClient, c.baseURL, token, "/drivers", time.Time{}, "", "")
-}
-
-func (c *apiClient) GetEmployees(ctx context.Context, token string) ([]dto.Employee, error) {
-	return fetchAndAggregate[dto.Employee](ctx, c.httpClient, c.baseURL, token, "/employees", time.Time{}, "", "")
-}
-
-// fetchAndAggregate é agora uma FUNÇÃO genérica, não um método.
-// Ela recebe httpClient e baseURL como parâmetros.
-func fetchAndAggregate[T any](ctx context.Context, httpClient *http.Client, baseURL, token, path string, since time.Time, filterProperty, filterValue string) ([]T, error) {
-	var allResults []T
-	const limit = 100
-	start := 0
-
-	for {
-		q := url.Values{}
-		q.Set("start", strconv.Itoa(start))
-		q.Set("limit", strconv.Itoa(limit))
-		q.Set("sort", "true")
-
-		if !since.IsZero() {
-			q.Set("startDate", since.UTC().Format("2006-01-02T15-04-05Z"))
-		}
-
-		if filterProperty != "" && filterValue != "" {
-			q.Set("property", filterProperty)
-			q.Set("search", filterValue)
-		}
-		fullURL := fmt.Sprintf("%s%s?%s", baseURL, path, q.Encode())
-		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request for %s: %w", path, err)
-		}
-		req.Header.Add("Authorization", "Bearer "+token)
-
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("request to %s failed: %w", path, err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			return nil, fmt.Errorf("request to %s got status %s, body: %s", path, resp.Status, string(body))
-		}
-
-		var wrapper struct {
-			Success bool              `json:"success"`
-			Data    []json.RawMessage `json:"data"`
-		}
-
-		if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
-			resp.Body.Close()
-			return nil, fmt.Errorf("failed to decode wrapper for %s: %w", path, err)
-		}
-		resp.Body.Close()
-
-		if !wrapper.Success {
-			return nil, fmt.Errorf("api call to %s was not successful", path)
-		}
-
-		// Decodifica cada item da página para o tipo genérico T
-		for _, raw := range wrapper.Data {
-			var item T
-			if err := json.Unmarshal(raw, &item); err != nil {
-				// Apenas loga o erro e continua, para não parar o job por um único registro malformado
-				log.Printf("Warning: failed to unmarshal item from %s: %v", path, err)
-				continue
-			}
-			allResults = append(allResults, item)
-		}
-
-		log.Printf("Fetched %d records from %s (total so far: %d)", len(wrapper.Data), path, len(allResults))
-
-		if len(wrapper.Data) < limit {
-			break
-		}
-
-		start += limit
-	}
-	return allResults, nil
+	return wrapper.Data, nil
 }
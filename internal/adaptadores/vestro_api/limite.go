@@ -0,0 +1,53 @@
+package vestro_api
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// requestLimiter limita quantas requisições à Vestro ficam em andamento ao mesmo tempo,
+// somando todas as sessões (e portanto todos os produtores) que usam o mesmo cliente.
+type requestLimiter struct {
+	slots chan struct{} // nil = sem limite
+}
+
+func newRequestLimiter(maxInFlight int) *requestLimiter {
+	if maxInFlight <= 0 {
+		return &requestLimiter{}
+	}
+	return &requestLimiter{slots: make(chan struct{}, maxInFlight)}
+}
+
+// do espera uma vaga e executa a requisição. A vaga só é liberada quando o corpo
+// da resposta é fechado, já que a leitura do corpo ainda ocupa a conexão.
+func (l *requestLimiter) do(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	if l.slots == nil {
+		return httpClient.Do(req)
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		<-l.slots
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: sync.OnceFunc(func() { <-l.slots })}
+	return resp, nil
+}
+
+// releasingBody devolve a vaga do limitador ao fechar o corpo da resposta.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,259 @@
+package vestro_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+	"vestro/internal/dto"
+)
+
+// pageLimit é a quantidade de registros pedida por página à Vestro.
+const pageLimit = 100
+
+// fetchAndAggregate é agora uma FUNÇÃO genérica, não um método.
+// Ela percorre todas as páginas via streamPages e junta os registros em memória;
+// para volumes grandes prefira stream, que não acumula nada.
+// Registros malformados já foram logados por fetchPage e ficam de fora.
+func fetchAndAggregate[T any](ctx context.Context, s *session, path string, window dto.TimeRange, filterProperty, filterValue string) ([]T, error) {
+	var allResults []T
+	for page, err := range streamPages[T](ctx, s, path, window, filterProperty, filterValue) {
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range page {
+			if entry.invalid == nil {
+				allResults = append(allResults, entry.item)
+			}
+		}
+	}
+	return allResults, nil
+}
+
+// stream entrega os registros um a um, buscando a próxima página só quando a atual
+// é consumida. Um registro malformado é entregue, na sua posição, como um *dto.RecordError
+// e a sequência continua; qualquer outro erro é o último elemento da sequência.
+func stream[T any](ctx context.Context, s *session, path string, window dto.TimeRange, filterProperty, filterValue string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range streamPages[T](ctx, s, path, window, filterProperty, filterValue) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, entry := range page {
+				var err error
+				if entry.invalid != nil {
+					err = entry.invalid
+				}
+				if !yield(entry.item, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// streamPages entrega cada página decodificada, em ordem, assim que ela está disponível.
+// Quando a primeira página informa o total (count), as seguintes são buscadas em paralelo,
+// em janelas de até PageConcurrency páginas, para limitar a memória usada.
+// A iteração para quando o consumidor interrompe o range, quando o contexto é cancelado ou na última página.
+func streamPages[T any](ctx context.Context, s *session, path string, window dto.TimeRange, filterProperty, filterValue string) iter.Seq2[[]pageEntry[T], error] {
+	return func(yield func([]pageEntry[T], error) bool) {
+		first, err := fetchPage[T](ctx, s, path, 0, window, filterProperty, filterValue)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		s.logf("Fetched %d records from %s (total so far: %d)", first.received, path, first.received)
+		if !yield(first.items, nil) || first.received < pageLimit {
+			return
+		}
+
+		received := first.received
+		start := pageLimit
+		concurrency := s.client.opts.PageConcurrency
+
+		if first.count > 0 && concurrency > 1 {
+			totalPages := (first.count + pageLimit - 1) / pageLimit
+			for page := 1; page < totalPages; page += concurrency {
+				batch := fetchWindow[T](ctx, s, path, page, min(concurrency, totalPages-page), window, filterProperty, filterValue)
+				for _, result := range batch {
+					if result.err != nil {
+						yield(nil, result.err)
+						return
+					}
+					received += result.received
+					s.logf("Fetched %d records from %s (total so far: %d of %d)", result.received, path, received, first.count)
+					if !yield(result.items, nil) {
+						return
+					}
+				}
+				start = (page + len(batch)) * pageLimit
+				if last := batch[len(batch)-1]; last.received < pageLimit {
+					checkCount(s, path, received, first.count)
+					return
+				}
+			}
+			// A última página veio cheia: surgiram registros durante a busca, então
+			// seguimos sequencialmente até o fim.
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, fmt.Errorf("fetch of %s interrupted: %w", path, err))
+				return
+			}
+
+			result, err := fetchPage[T](ctx, s, path, start, window, filterProperty, filterValue)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			received += result.received
+			s.logf("Fetched %d records from %s (total so far: %d)", result.received, path, received)
+
+			if !yield(result.items, nil) {
+				return
+			}
+			if result.received < pageLimit {
+				checkCount(s, path, received, first.count)
+				return
+			}
+			start += pageLimit
+		}
+	}
+}
+
+// pageResult é o resultado de uma página: os itens, quantos registros a API devolveu
+// (inclusive os posteriores à janela, que são descartados) e o total informado pela Vestro.
+type pageResult[T any] struct {
+	items    []pageEntry[T]
+	received int
+	count    int
+	err      error
+}
+
+// pageEntry é um registro da página: o item decodificado ou, se ele estiver malformado
+// (data ou número inválido), o erro que o identifica.
+type pageEntry[T any] struct {
+	item    T
+	invalid *dto.RecordError
+}
+
+// fetchWindow busca size páginas a partir de firstPage em paralelo e as devolve na ordem.
+func fetchWindow[T any](ctx context.Context, s *session, path string, firstPage, size int, window dto.TimeRange, filterProperty, filterValue string) []pageResult[T] {
+	results := make([]pageResult[T], size)
+	var wg sync.WaitGroup
+	for i := range size {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := fetchPage[T](ctx, s, path, (firstPage+i)*pageLimit, window, filterProperty, filterValue)
+			result.err = err
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// checkCount compara o total recebido com o count da primeira página. A divergência é só
+// logada: registros podem ser criados ou removidos na Vestro durante a paginação.
+func checkCount(s *session, path string, received, count int) {
+	if count > 0 && received != count {
+		s.logf("Warning: %s reported %d records but %d were received", path, count, received)
+	}
+}
+
+// fetchPage busca uma única página a partir do registro start.
+func fetchPage[T any](ctx context.Context, s *session, path string, start int, window dto.TimeRange, filterProperty, filterValue string) (pageResult[T], error) {
+	q := url.Values{}
+	q.Set("start", strconv.Itoa(start))
+	q.Set("limit", strconv.Itoa(pageLimit))
+	q.Set("sort", "true")
+
+	if !window.Since.IsZero() {
+		q.Set("startDate", dto.FormatVestroTime(window.Since))
+	}
+	if !window.Until.IsZero() && s.client.opts.EndDateParam != "" {
+		q.Set(s.client.opts.EndDateParam, dto.FormatVestroTime(window.Until))
+	}
+
+	if filterProperty != "" && filterValue != "" {
+		q.Set("property", filterProperty)
+		q.Set("search", filterValue)
+	}
+	resp, err := s.get(ctx, path, q)
+	if err != nil {
+		return pageResult[T]{}, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return pageResult[T]{}, fmt.Errorf("request to %s got status %s, body: %s", path, resp.Status, string(body))
+	}
+
+	var wrapper struct {
+		Success bool              `json:"success"`
+		Data    []json.RawMessage `json:"data"`
+		Count   int               `json:"count"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
+		return pageResult[T]{}, fmt.Errorf("failed to decode wrapper for %s: %w", path, err)
+	}
+
+	if !wrapper.Success {
+		return pageResult[T]{}, fmt.Errorf("api call to %s was not successful", path)
+	}
+
+	// Decodifica cada item da página para o tipo genérico T. Um registro malformado não
+	// para o job: ele segue como erro para quem consome a página decidir o que fazer.
+	items := make([]pageEntry[T], 0, len(wrapper.Data))
+	for _, raw := range wrapper.Data {
+		var item T
+		if err := json.Unmarshal(raw, &item); err != nil {
+			s.logf("Warning: failed to unmarshal item %s from %s: %v", recordID(raw), path, err)
+			items = append(items, pageEntry[T]{invalid: &dto.RecordError{ID: recordID(raw), Err: err}})
+			continue
+		}
+		if afterWindow(window, item) {
+			continue
+		}
+		items = append(items, pageEntry[T]{item: item})
+	}
+	return pageResult[T]{items: items, received: len(wrapper.Data), count: wrapper.Count}, nil
+}
+
+// dated é implementado pelos registros que têm data (os transacionais).
+type dated interface {
+	Timestamp() time.Time
+}
+
+// afterWindow indica se o registro é posterior ao fim da janela. O fim é exclusivo,
+// para que janelas consecutivas (como os dias do backfill) não repitam registros.
+func afterWindow(window dto.TimeRange, item any) bool {
+	record, ok := item.(dated)
+	return ok && !window.Until.IsZero() && !record.Timestamp().Before(window.Until)
+}
+
+// recordID extrai o "id" de um registro bruto para identificá-lo nos logs e no relatório.
+// Vazio quando o registro não traz um id legível.
+func recordID(raw json.RawMessage) string {
+	var record struct {
+		ID json.Number `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return ""
+	}
+	return record.ID.String()
+}
@@ -0,0 +1,154 @@
+package vestro_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy define como as chamadas à API Vestro são repetidas em caso de falhas transitórias.
+type RetryPolicy struct {
+	MaxAttempts   int           // Tentativas por chamada (incluindo a primeira). <= 1 desativa o retry.
+	BaseDelay     time.Duration // Espera inicial do backoff exponencial.
+	MaxDelay      time.Duration // Teto da espera entre tentativas (também limita o Retry-After).
+	TotalBudget   int           // Máximo de retries somados em uma execução do job (ver ResetRetryBudget). 0 = sem limite.
+	RetryStatuses []int         // Status HTTP considerados transitórios.
+}
+
+// DefaultRetryPolicy retorna uma política conservadora para os jobs noturnos.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:   5,
+		BaseDelay:     500 * time.Millisecond,
+		MaxDelay:      30 * time.Second,
+		TotalBudget:   200,
+		RetryStatuses: []int{408, 425, 429, 500, 502, 503, 504},
+	}
+}
+
+// retrier executa requisições aplicando a RetryPolicy e controlando o orçamento global de retries.
+type retrier struct {
+	policy  RetryPolicy
+	retries atomic.Int64
+}
+
+func newRetrier(policy RetryPolicy) *retrier {
+	if len(policy.RetryStatuses) == 0 {
+		policy.RetryStatuses = DefaultRetryPolicy().RetryStatuses
+	}
+	return &retrier{policy: policy}
+}
+
+// do executa a requisição criada por newReq, repetindo-a enquanto a falha for transitória.
+// A requisição é recriada a cada tentativa para que corpo e headers estejam sempre íntegros.
+// Apenas métodos idempotentes (GET/HEAD) são repetidos. Os logs saem por logf, para
+// identificarem o login da sessão que fez a chamada.
+func (r *retrier) do(ctx context.Context, logf func(format string, args ...any), send func(*http.Request) (*http.Response, error), newReq func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := send(req)
+		if !r.shouldRetry(req, resp, err, attempt) {
+			return resp, err
+		}
+
+		delay := r.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(retryAfter, r.policy.MaxDelay)
+			}
+			resp.Body.Close()
+		}
+
+		if !r.takeBudget() {
+			logf("Warning: Vestro retry budget exhausted, giving up on %s", req.URL.Path)
+			if err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("request to %s got status %s and retry budget is exhausted", req.URL.Path, resp.Status)
+		}
+
+		logf("Retrying %s %s in %v (attempt %d/%d): %s", req.Method, req.URL.Path, delay, attempt+1, r.policy.MaxAttempts, describeFailure(resp, err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *retrier) shouldRetry(req *http.Request, resp *http.Response, err error, attempt int) bool {
+	if attempt >= r.policy.MaxAttempts {
+		return false
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if err != nil {
+		// Cancelamentos do próprio job não são falhas transitórias.
+		return req.Context().Err() == nil && !errors.Is(err, context.Canceled)
+	}
+	for _, status := range r.policy.RetryStatuses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// takeBudget consome um retry do orçamento global. Retorna false se ele já estiver esgotado.
+func (r *retrier) takeBudget() bool {
+	if r.policy.TotalBudget <= 0 {
+		return true
+	}
+	return r.retries.Add(1) <= int64(r.policy.TotalBudget)
+}
+
+// resetBudget devolve o orçamento inteiro para a próxima execução.
+func (r *retrier) resetBudget() {
+	r.retries.Store(0)
+}
+
+// backoff calcula a espera com backoff exponencial e "full jitter".
+func (r *retrier) backoff(attempt int) time.Duration {
+	ceiling := r.policy.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > r.policy.MaxDelay {
+		ceiling = r.policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// parseRetryAfter interpreta o header Retry-After, tanto em segundos quanto em data HTTP.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+func describeFailure(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "status " + resp.Status
+}
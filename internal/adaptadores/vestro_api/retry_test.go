@@ -0,0 +1,57 @@
+package vestro_api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "seconds", value: "120", want: 2 * time.Minute, wantOK: true},
+		{name: "zero seconds", value: "0", want: 0, wantOK: true},
+		{name: "date in the past", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOK: true},
+		{name: "empty", value: ""},
+		{name: "negative seconds", value: "-5"},
+		{name: "fractional seconds", value: "1.5"},
+		{name: "garbage", value: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, %t, want %v, %t", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfterFutureDate(t *testing.T) {
+	at := time.Now().Add(90 * time.Second).UTC()
+	got, ok := parseRetryAfter(at.Format(http.TimeFormat))
+	// A data HTTP tem precisão de segundos
+	if !ok || got <= 88*time.Second || got > 90*time.Second {
+		t.Errorf("parseRetryAfter(%s) = %v, %t, want about 90s", at.Format(http.TimeFormat), got, ok)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	r := newRetrier(RetryPolicy{MaxAttempts: 3, TotalBudget: 2})
+	for i := range 2 {
+		if !r.takeBudget() {
+			t.Fatalf("retry %d was refused within the budget", i+1)
+		}
+	}
+	if r.takeBudget() {
+		t.Fatal("retry over the budget was allowed")
+	}
+	r.resetBudget()
+	if !r.takeBudget() {
+		t.Fatal("retry was refused after resetting the budget")
+	}
+}
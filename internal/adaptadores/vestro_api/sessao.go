@@ -0,0 +1,188 @@
+package vestro_api
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+	"vestro/internal/dto"
+)
+
+// session representa o login de um produtor na Vestro. Ela guarda o token de acesso
+// e as credenciais, para poder se reautenticar sozinha quando a API responder 401.
+type session struct {
+	client   *apiClient
+	login    string
+	password string
+
+	mu           sync.Mutex
+	access       string
+	sessionToken string
+	expiresAt    time.Time // zero = validade desconhecida
+}
+
+// logf registra uma mensagem identificando o login, para que os logs continuem
+// atribuíveis quando vários produtores são processados em paralelo.
+func (s *session) logf(format string, args ...any) {
+	log.Printf("[vestro %s] "+format, append([]any{s.login}, args...)...)
+}
+
+// validToken retorna o token de acesso atual, renovando-o antes se ele estiver
+// perto de expirar (ou se a sessão ainda não tiver feito login).
+func (s *session) validToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.access != "" && !s.expiringSoon() {
+		return s.access, nil
+	}
+	if err := s.renewLocked(ctx); err != nil {
+		return "", err
+	}
+	return s.access, nil
+}
+
+// reauthenticate renova o token, a menos que outra goroutine já tenha trocado
+// o token que falhou (staleToken) enquanto esperávamos o lock.
+func (s *session) reauthenticate(ctx context.Context, staleToken string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.access != "" && s.access != staleToken {
+		return s.access, nil
+	}
+	if err := s.renewLocked(ctx); err != nil {
+		return "", err
+	}
+	return s.access, nil
+}
+
+func (s *session) expiringSoon() bool {
+	if s.expiresAt.IsZero() {
+		return false
+	}
+	return time.Now().Add(s.client.opts.RefreshMargin).After(s.expiresAt)
+}
+
+// renewLocked obtém um novo token. Usa o Session quando a rota de refresh está
+// configurada e cai para um novo login se o refresh falhar. Exige s.mu travado.
+func (s *session) renewLocked(ctx context.Context) error {
+	if s.sessionToken != "" && s.client.opts.RefreshPath != "" {
+		auth, err := s.client.refreshToken(ctx, s.sessionToken)
+		if err == nil {
+			s.store(auth, "refreshed")
+			return nil
+		}
+		s.logf("Warning: Vestro session refresh failed: %v. Logging in again.", err)
+	}
+
+	auth, err := s.client.requestToken(ctx, s.login, s.password)
+	if err != nil {
+		return err
+	}
+	s.store(auth, "issued")
+	return nil
+}
+
+// store guarda os tokens recebidos e calcula a expiração, pela claim "exp"
+// do JWT ou, na falta dela, pelo TTL configurado.
+func (s *session) store(auth dto.AuthResponse, action string) {
+	s.access = auth.Access
+	if auth.Session != "" {
+		s.sessionToken = auth.Session
+	}
+
+	now := time.Now()
+	if exp, ok := jwtExpiry(auth.Access); ok {
+		s.expiresAt = exp
+	} else if s.client.opts.TokenTTL > 0 {
+		s.expiresAt = now.Add(s.client.opts.TokenTTL)
+	} else {
+		s.expiresAt = time.Time{}
+	}
+
+	if s.expiresAt.IsZero() {
+		s.logf("Vestro token %s (expiry unknown)", action)
+		return
+	}
+	s.logf("Vestro token %s, valid until %s (lifetime %v)", action, s.expiresAt.Format(time.RFC3339), s.expiresAt.Sub(now).Round(time.Second))
+}
+
+// get executa um GET autenticado com retry. Se a Vestro responder 401, a sessão
+// se reautentica uma única vez e repete a requisição com o novo token.
+func (s *session) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	fullURL := fmt.Sprintf("%s%s?%s", s.client.baseURL, path, query.Encode())
+
+	do := func(token string) (*http.Response, error) {
+		return s.client.retrier.do(ctx, s.logf, s.client.send, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create request for %s: %w", path, err)
+			}
+			req.Header.Add("Authorization", "Bearer "+token)
+			return req, nil
+		})
+	}
+
+	token, err := s.validToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not obtain Vestro token: %w", err)
+	}
+	resp, err := do(token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	s.logf("Vestro token was rejected on %s, re-authenticating...", path)
+	token, err = s.reauthenticate(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("re-authentication failed: %w", err)
+	}
+	return do(token)
+}
+
+// As funções abaixo chamam a *função* genérica fetchAndAggregate,
+// passando a sessão do produtor.
+// O filtro vira os parâmetros "property" e "search" da Vestro.
+func (s *session) GetSupplies(ctx context.Context, window dto.TimeRange, filter dto.TransactionFilter) ([]dto.Supply, error) {
+	return fetchAndAggregate[dto.Supply](ctx, s, "/supplies", window, filter.Property(), filter.Value)
+}
+
+func (s *session) GetProductSales(ctx context.Context, window dto.TimeRange, filter dto.TransactionFilter) ([]dto.ProductSale, error) {
+	return fetchAndAggregate[dto.ProductSale](ctx, s, "/product/sales", window, filter.Property(), filter.Value)
+}
+
+// StreamSupplies e StreamProductSales entregam os registros da janela página a página,
+// sem acumular o período inteiro em memória.
+func (s *session) StreamSupplies(ctx context.Context, window dto.TimeRange, filter dto.TransactionFilter) iter.Seq2[dto.Supply, error] {
+	return stream[dto.Supply](ctx, s, "/supplies", window, filter.Property(), filter.Value)
+}
+
+func (s *session) StreamProductSales(ctx context.Context, window dto.TimeRange, filter dto.TransactionFilter) iter.Seq2[dto.ProductSale, error] {
+	return stream[dto.ProductSale](ctx, s, "/product/sales", window, filter.Property(), filter.Value)
+}
+
+func (s *session) GetProducts(ctx context.Context) ([]dto.Product, error) {
+	return fetchAndAggregate[dto.Product](ctx, s, "/products", dto.TimeRange{}, "", "")
+}
+
+func (s *session) GetFuelTypes(ctx context.Context) ([]dto.FuelType, error) {
+	return fetchAndAggregate[dto.FuelType](ctx, s, "/fuel/types", dto.TimeRange{}, "", "")
+}
+
+func (s *session) GetVehicles(ctx context.Context) ([]dto.Vehicle, error) {
+	return fetchAndAggregate[dto.Vehicle](ctx, s, "/vehicles", dto.TimeRange{}, "", "")
+}
+
+func (s *session) GetDrivers(ctx context.Context) ([]dto.Driver, error) {
+	return fetchAndAggregate[dto.Driver](ctx, s, "/drivers", dto.TimeRange{}, "", "")
+}
+
+func (s *session) GetEmployees(ctx context.Context) ([]dto.Employee, error) {
+	return fetchAndAggregate[dto.Employee](ctx, s, "/employees", dto.TimeRange{}, "", "")
+}
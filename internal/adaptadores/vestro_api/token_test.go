@@ -0,0 +1,39 @@
+package vestro_api
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestJWTExpiry(t *testing.T) {
+	token := func(claims string) string {
+		return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".c2lnbmF0dXJl"
+	}
+	tests := []struct {
+		name   string
+		token  string
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "integer exp", token: token(`{"sub":"x","exp":1767225600}`), want: time.Unix(1767225600, 0), wantOK: true},
+		{name: "fractional exp", token: token(`{"exp":1767225600.75}`), want: time.Unix(1767225600, 0), wantOK: true},
+		{name: "padded payload", token: "a." + base64.URLEncoding.EncodeToString([]byte(`{"exp":1767225600}`)) + ".c", want: time.Unix(1767225600, 0), wantOK: true},
+		{name: "no exp", token: token(`{"sub":"x"}`)},
+		{name: "zero exp", token: token(`{"exp":0}`)},
+		{name: "string exp", token: token(`{"exp":"soon"}`)},
+		{name: "payload is not json", token: token(`not json`)},
+		{name: "payload is not base64", token: "a.%%%.c"},
+		{name: "opaque token", token: "0f1e2d3c4b5a"},
+		{name: "two parts", token: "a." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1767225600}`))},
+		{name: "empty", token: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := jwtExpiry(tt.token)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("jwtExpiry() = %v, %t, want %v, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package agendador
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Options define quando o job roda no modo serve.
+type Options struct {
+	// Schedule é uma expressão cron de 5 campos ("0 * * * *") ou um descritor ("@hourly").
+	// Aceita o prefixo "CRON_TZ=America/Sao_Paulo". Vazio usa Interval.
+	Schedule string
+	// Interval é o intervalo entre o fim de uma execução e o início da próxima.
+	Interval time.Duration
+	// Jitter é um atraso aleatório de até Jitter somado a cada execução, para espalhar a carga na Vestro.
+	Jitter time.Duration
+}
+
+// Job é uma execução do job. Quando stop é fechado, o job deve terminar o que está fazendo
+// (por exemplo, o produtor atual) e retornar sem começar nada novo.
+type Job func(ctx context.Context, stop <-chan struct{})
+
+type scheduler struct {
+	schedule cron.Schedule
+	jitter   time.Duration
+	job      Job
+}
+
+func New(opts Options, job Job) (*scheduler, error) {
+	var schedule cron.Schedule
+	switch {
+	case opts.Schedule != "":
+		parsed, err := cron.ParseStandard(opts.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", opts.Schedule, err)
+		}
+		schedule = parsed
+	case opts.Interval > 0:
+		schedule = cron.Every(opts.Interval)
+	default:
+		return nil, fmt.Errorf("either a schedule or a positive interval is required")
+	}
+	return &scheduler{schedule: schedule, jitter: max(opts.Jitter, 0), job: job}, nil
+}
+
+// Run executa o job no horário agendado até ctx ser cancelado. As execuções nunca se
+// sobrepõem: a próxima é calculada a partir do fim da anterior, e horários perdidos
+// enquanto uma execução ainda rodava são pulados.
+// Quando ctx é cancelado durante uma execução, o job recebe o sinal de parada e Run
+// espera ele terminar antes de retornar.
+func (s *scheduler) Run(ctx context.Context) {
+	for {
+		next := s.schedule.Next(time.Now())
+		if s.jitter > 0 {
+			next = next.Add(rand.N(s.jitter))
+		}
+		log.Printf("Next import run at %s", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("Scheduler stopped.")
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(ctx)
+		if ctx.Err() != nil {
+			log.Println("Scheduler stopped after finishing the current run.")
+			return
+		}
+	}
+}
+
+// runOnce roda o job com um contexto que não é cancelado junto com ctx, para que o trabalho
+// em andamento termine; o cancelamento de ctx apenas fecha o canal de parada.
+func (s *scheduler) runOnce(ctx context.Context) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.job(context.WithoutCancel(ctx), stop)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Println("Shutdown requested, waiting for the current run to finish its in-flight producers...")
+		close(stop)
+		<-done
+	}
+}
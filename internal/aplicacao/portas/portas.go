@@ -2,7 +2,7 @@ package portas
 
 import (
 	"context"
-	"time"
+	"iter"
 	"vestro/internal/dto"
 )
 
@@ -11,19 +11,68 @@ type UserProvider interface {
 	GetUsersToIntegrate(ctx context.Context) ([]dto.UserToIntegrate, error)
 }
 
-// VestroAPIClient foi atualizada para receber as credenciais na autenticação.
+// VestroAPIClient autentica um produtor e devolve a sessão usada nas buscas.
 type VestroAPIClient interface {
-	Authenticate(ctx context.Context, login, password string) (string, error)
-	GetSupplies(ctx context.Context, token string, since time.Time, userIdentifier string) ([]dto.Supply, error)
-	GetProductSales(ctx context.Context, token string, since time.Time, userIdentifier string) ([]dto.ProductSale, error)
-	GetProducts(ctx context.Context, token string) ([]dto.Product, error)
-	GetFuelTypes(ctx context.Context, token string) ([]dto.FuelType, error)
-	GetVehicles(ctx context.Context, token string) ([]dto.Vehicle, error)
-	GetDrivers(ctx context.Context, token string) ([]dto.Driver, error)
-	GetEmployees(ctx context.Context, token string) ([]dto.Employee, error)
+	Authenticate(ctx context.Context, login, password string) (VestroSession, error)
+}
+
+// VestroSession é o login de um produtor na Vestro. Ela cuida do token
+// (inclusive da reautenticação), então os métodos não recebem mais o token.
+type VestroSession interface {
+	// Dados transacionais da janela informada; Until zero busca até agora.
+	GetSupplies(ctx context.Context, window dto.TimeRange, filter dto.TransactionFilter) ([]dto.Supply, error)
+	GetProductSales(ctx context.Context, window dto.TimeRange, filter dto.TransactionFilter) ([]dto.ProductSale, error)
+	// Versões em streaming dos dados transacionais: os registros chegam página a página
+	// e a iteração para ao interromper o range ou ao cancelar o contexto. Um registro
+	// malformado chega como *dto.RecordError, na sua posição, e a iteração continua.
+	StreamSupplies(ctx context.Context, window dto.TimeRange, filter dto.TransactionFilter) iter.Seq2[dto.Supply, error]
+	StreamProductSales(ctx context.Context, window dto.TimeRange, filter dto.TransactionFilter) iter.Seq2[dto.ProductSale, error]
+	GetProducts(ctx context.Context) ([]dto.Product, error)
+	GetFuelTypes(ctx context.Context) ([]dto.FuelType, error)
+	GetVehicles(ctx context.Context) ([]dto.Vehicle, error)
+	GetDrivers(ctx context.Context) ([]dto.Driver, error)
+	GetEmployees(ctx context.Context) ([]dto.Employee, error)
 }
 
 // Notifier continua o mesmo.
 type Notifier interface {
 	Send(ctx context.Context, payload dto.IntegrationPayload) error
 }
+
+// SyncAcknowledger confirma ao Agriwin a janela importada de um produtor depois do envio.
+type SyncAcknowledger interface {
+	Acknowledge(ctx context.Context, ack dto.SyncAcknowledgement) error
+}
+
+// CheckpointStore guarda, por produtor e entidade, o registro mais novo já entregue ao Agriwin.
+type CheckpointStore interface {
+	// Load retorna o checkpoint salvo; ok é false quando ainda não existe nenhum.
+	Load(ctx context.Context, produtorID int, entity string) (cp dto.Checkpoint, ok bool, err error)
+	Save(ctx context.Context, cp dto.Checkpoint) error
+	Close() error
+}
+
+// MasterDataHashes guarda o hash do último conteúdo de cada lista de dados mestres entregue
+// ao Agriwin, para que as listas que não mudaram fiquem fora dos próximos envios.
+type MasterDataHashes interface {
+	// Load retorna o hash salvo; ok é false quando a lista ainda não foi entregue.
+	Load(ctx context.Context, key dto.MasterDataKey) (hash string, ok bool, err error)
+	Save(ctx context.Context, key dto.MasterDataKey, hash string) error
+}
+
+// SnapshotStore guarda a última versão das listas de dados mestres de cada produtor, base
+// para detectar as mudanças enviadas ao Agriwin.
+type SnapshotStore interface {
+	// Load retorna o snapshot salvo; ok é false quando a lista ainda não tem nenhum.
+	Load(ctx context.Context, produtorID int, entity string) (snap dto.Snapshot, ok bool, err error)
+	Save(ctx context.Context, snap dto.Snapshot) error
+}
+
+// DeadLetterQueue guarda os envios ao Agriwin que falharam, para serem reenviados depois.
+type DeadLetterQueue interface {
+	// Put grava a entrada, substituindo a que tiver o mesmo ID.
+	Put(ctx context.Context, entry dto.DeadLetter) error
+	// List retorna as entradas da mais antiga para a mais nova.
+	List(ctx context.Context) ([]dto.DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+}
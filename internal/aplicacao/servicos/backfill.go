@@ -0,0 +1,190 @@
+package servicos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+	"vestro/internal/dto"
+)
+
+// BackfillRequest define uma reimportação explícita: os produtores e o período [From, To).
+type BackfillRequest struct {
+	ProdutorIDs []int
+	From        time.Time
+	To          time.Time
+}
+
+// RunBackfill reimporta o período pedido para cada produtor, dia a dia e em ordem, usando o
+// mesmo envio da importação regular (lotes, partes e fila de reenvio). A marca d'água da
+// importação regular não é alterada e a janela não é confirmada ao Agriwin.
+// O último dia concluído de cada produtor é gravado no CheckpointStore, então rodar o mesmo
+// backfill de novo continua de onde ele parou.
+func (s *ImporterService) RunBackfill(ctx context.Context, req BackfillRequest) (*dto.JobReport, error) {
+	if !req.From.Before(req.To) {
+		return nil, fmt.Errorf("invalid backfill range: %s is not before %s", req.From.Format(time.RFC3339), req.To.Format(time.RFC3339))
+	}
+	report := &dto.JobReport{RunID: "backfill-" + newRunID(), StartedAt: time.Now()}
+	log.Printf("Starting Vestro backfill from %s to %s for producer(s) %v (run ID: %s)", req.From.Format(time.RFC3339), req.To.Format(time.RFC3339), req.ProdutorIDs, report.RunID)
+
+	// As credenciais vêm da mesma lista da importação regular
+	users, err := s.userProvider.GetUsersToIntegrate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get users to integrate: %w", err)
+	}
+	selected := make([]dto.UserToIntegrate, 0, len(req.ProdutorIDs))
+	for _, id := range req.ProdutorIDs {
+		i := slices.IndexFunc(users, func(u dto.UserToIntegrate) bool { return u.ProdutorID == id })
+		if i < 0 {
+			return nil, fmt.Errorf("producer %d is not in the Agriwin users list", id)
+		}
+		selected = append(selected, users[i])
+	}
+
+	report.Producers = make([]dto.ProducerReport, len(selected))
+	for i, user := range selected {
+		if ctx.Err() != nil {
+			notStarted(report, selected, i)
+			break
+		}
+		report.Producers[i] = s.backfillProducer(ctx, report.RunID, user, req)
+	}
+
+	if ctx.Err() != nil {
+		report.Interrupted = context.Cause(ctx).Error()
+	}
+	report.FinishedAt = time.Now()
+	log.Printf("------------------ Backfill finished: %d producer(s) processed, %d failed ------------------", len(report.Producers), report.FailedCount())
+	return report, nil
+}
+
+// backfillProducer reimporta o período de um produtor, um dia por vez.
+func (s *ImporterService) backfillProducer(ctx context.Context, runID string, user dto.UserToIntegrate, req BackfillRequest) dto.ProducerReport {
+	logger := log.New(log.Writer(), fmt.Sprintf("[backfill %d] ", user.ProdutorID), log.Flags()|log.Lmsgprefix)
+	started := time.Now()
+	result := dto.ProducerReport{ProdutorID: user.ProdutorID, Records: make(map[string]int)}
+	finish := func(status dto.ProducerStatus, err error) dto.ProducerReport {
+		result.Status = interruptedStatus(ctx, status)
+		result.DurationMs = time.Since(started).Milliseconds()
+		if err != nil {
+			result.Error = err.Error()
+		}
+		return result
+	}
+
+	filter, err := user.TransactionFilter(s.opts.DefaultFilterMode)
+	if err != nil {
+		logger.Printf("ERROR: Invalid Vestro filter for producer %d: %v. Skipping.", user.ProdutorID, err)
+		return finish(dto.StatusFetchFailed, err)
+	}
+	entities, err := user.Entities(s.opts.Entities)
+	if err != nil {
+		logger.Printf("ERROR: Invalid entity selection for producer %d: %v. Skipping.", user.ProdutorID, err)
+		return finish(dto.StatusFetchFailed, err)
+	}
+	if !entities.Has(dto.EntitySupplies) && !entities.Has(dto.EntityProductSales) {
+		logger.Printf("No transactional entity selected for producer %d (%s), nothing to backfill.", user.ProdutorID, entities)
+		return finish(dto.StatusNoData, nil)
+	}
+	session, err := s.apiClient.Authenticate(ctx, user.Login, user.Senha)
+	if err != nil {
+		logger.Printf("ERROR: Vestro authentication failed for user '%s': %v. Skipping.", user.Login, err)
+		return finish(dto.StatusAuthFailed, err)
+	}
+
+	progress := s.loadBackfillProgress(ctx, logger, user.ProdutorID, req)
+	days := backfillDays(req.From, req.To)
+	held := false
+	for i, day := range days {
+		if !day.Until.After(progress.LastTimestamp) {
+			continue
+		}
+		logger.Printf("Day %d/%d (%s)...", i+1, len(days), day)
+
+		supplies := fixedWindow(user.ProdutorID, dto.EntitySupplies, day)
+		sales := fixedWindow(user.ProdutorID, dto.EntityProductSales, day)
+		supplies.skipped = !entities.Has(dto.EntitySupplies)
+		sales.skipped = !entities.Has(dto.EntityProductSales)
+		payload := &dto.IntegrationPayload{ProdutorID: user.ProdutorID, FetchedAt: time.Now()}
+		d := newDelivery(runID, fmt.Sprintf("%s-%d-%s", runID, user.ProdutorID, day.Since.Format("20060102")), supplies, sales, day.Until)
+
+		batches, err := s.forwardTransactional(ctx, logger, d, session, filter, supplies, sales, payload, &result)
+		result.Batches += batches
+		if err != nil {
+			logger.Printf("ERROR: Backfill of producer %d stopped on day %s: %v", user.ProdutorID, day.Since.Format(time.DateOnly), err)
+			if errors.Is(err, errSendFailed) {
+				result.DeadLettered = errors.Is(err, errDeadLettered)
+				return finish(dto.StatusSendFailed, err)
+			}
+			return finish(dto.StatusFetchFailed, err)
+		}
+
+		// Um dia com registros inválidos segura o progresso: uma nova execução do mesmo
+		// backfill volta a ele, em vez de dá-lo como concluído
+		if supplies.held || sales.held {
+			held = true
+		}
+		if !held {
+			progress.Advance(day.Until, 0)
+			progress.UpdatedAt = time.Now()
+			s.saveBackfillProgress(ctx, logger, progress)
+		}
+		logger.Printf("Day %d/%d done: %d supplies, %d product sales so far.", i+1, len(days), result.Records[dto.EntitySupplies], result.Records[dto.EntityProductSales])
+	}
+
+	if n := len(result.InvalidRecords); n > 0 {
+		logger.Printf("Warning: skipped %d invalid record(s) for producer %d; backfill progress stays before the first one.", n, user.ProdutorID)
+		return finish(dto.StatusPartial, fmt.Errorf("skipped %d invalid record(s)", n))
+	}
+	if result.Batches == 0 {
+		return finish(dto.StatusNoData, nil)
+	}
+	return finish(dto.StatusOK, nil)
+}
+
+// backfillDays divide [from, to) em janelas de um dia, no fuso de from.
+func backfillDays(from, to time.Time) []dto.TimeRange {
+	var days []dto.TimeRange
+	for start := from; start.Before(to); start = start.AddDate(0, 0, 1) {
+		end := start.AddDate(0, 0, 1)
+		if end.After(to) {
+			end = to
+		}
+		days = append(days, dto.TimeRange{Since: start, Until: end})
+	}
+	return days
+}
+
+// O progresso de um backfill é um checkpoint próprio, identificado pelo período pedido, cujo
+// LastTimestamp é o fim do último dia concluído.
+func backfillEntity(req BackfillRequest) string {
+	return fmt.Sprintf("backfill %s %s", req.From.UTC().Format(time.RFC3339), req.To.UTC().Format(time.RFC3339))
+}
+
+func (s *ImporterService) loadBackfillProgress(ctx context.Context, logger *log.Logger, produtorID int, req BackfillRequest) dto.Checkpoint {
+	progress := dto.Checkpoint{ProdutorID: produtorID, Entity: backfillEntity(req)}
+	if s.checkpoints == nil {
+		return progress
+	}
+	saved, ok, err := s.checkpoints.Load(ctx, produtorID, progress.Entity)
+	if err != nil {
+		logger.Printf("Warning: could not load backfill progress, starting from the beginning: %v", err)
+		return progress
+	}
+	if ok {
+		logger.Printf("Resuming backfill after %s", saved.LastTimestamp.Format(time.RFC3339))
+		return saved
+	}
+	return progress
+}
+
+func (s *ImporterService) saveBackfillProgress(ctx context.Context, logger *log.Logger, progress dto.Checkpoint) {
+	if s.checkpoints == nil {
+		return
+	}
+	if err := s.checkpoints.Save(ctx, progress); err != nil {
+		logger.Printf("Warning: failed to save backfill progress: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package servicos
+
+import (
+	"context"
+	"log"
+	"maps"
+	"time"
+	"vestro/internal/dto"
+)
+
+// entityWindow é a janela de busca de uma entidade transacional e o checkpoint
+// que acompanha os envios. Os registros do lote atual ficam em batch até o Agriwin aceitar
+// o envio; só então entram no checkpoint (data mais nova e IDs já vistos).
+type entityWindow struct {
+	fetch      dto.TimeRange
+	checkpoint dto.Checkpoint
+	// persist indica se o checkpoint é gravado no store a cada lote aceito. Janelas avulsas,
+	// como as do backfill, não podem mexer na marca d'água da importação regular.
+	persist bool
+	// skipped indica que a entidade não foi selecionada para a execução e não é buscada.
+	skipped bool
+
+	batch        map[int]time.Time // ID -> data dos registros no lote ainda não confirmado
+	deliveredAck dto.EntityAck
+	// queued são os IDs de lotes guardados na fila de reenvio. Eles ainda não estão no
+	// checkpoint, mas chegam ao Agriwin pelo replay e não são enviados de novo.
+	queued map[int]time.Time
+
+	// Depois de um registro inválido, o checkpoint não passa de holdAt (a data do último
+	// registro válido antes dele), para que a próxima execução o busque de novo. Os registros
+	// entregues depois dele não se repetem, pois continuam nos IDs vistos.
+	latest time.Time
+	held   bool
+	holdAt time.Time
+}
+
+// resumeWindow decide a partir de quando buscar uma entidade. Havendo checkpoint, a busca
+// continua de onde o último envio bem-sucedido parou, recuando FetchOverlap para pegar
+// registros lançados com atraso na Vestro; os repetidos são descartados pelos IDs vistos
+// do checkpoint. Sem checkpoint, usa a data informada pelo Agriwin e não recua: sem os IDs
+// vistos, a sobreposição reenviaria os registros dela. Nos dois casos a janela começa no
+// máximo FetchSince antes de until, o instante fixado no início do job, onde ela termina.
+// Um checkpoint seguro por um registro inválido, portanto, só o busca de novo até ele
+// sair da janela.
+func (s *ImporterService) resumeWindow(ctx context.Context, logger *log.Logger, user dto.UserToIntegrate, entity string, until time.Time) *entityWindow {
+	window := &entityWindow{
+		fetch:      dto.TimeRange{Since: user.Data, Until: until},
+		checkpoint: dto.Checkpoint{ProdutorID: user.ProdutorID, Entity: entity},
+		persist:    true,
+		batch:      make(map[int]time.Time),
+	}
+
+	if s.checkpoints != nil {
+		cp, ok, err := s.checkpoints.Load(ctx, user.ProdutorID, entity)
+		switch {
+		case err != nil:
+			logger.Printf("Warning: could not load %s checkpoint, using the Agriwin window: %v", entity, err)
+		case ok && !cp.LastTimestamp.IsZero():
+			logger.Printf("Resuming %s from checkpoint %s (id %d, %d recent ids)", entity, cp.LastTimestamp.Format(time.RFC3339), cp.LastID, len(cp.SeenIDs))
+			window.fetch.Since = cp.LastTimestamp.Add(-s.opts.FetchOverlap)
+			window.checkpoint = cp
+		case ok:
+			// Um registro inválido antes de qualquer válido segura a data, mas os IDs vistos
+			// continuam valendo para a janela do Agriwin
+			logger.Printf("Using the Agriwin window for %s with %d recent ids from the checkpoint", entity, len(cp.SeenIDs))
+			window.checkpoint = cp
+		}
+	}
+
+	// Garante que não buscamos um histórico muito longo
+	if until.Sub(window.fetch.Since) > s.opts.FetchSince {
+		window.fetch.Since = until.Add(-s.opts.FetchSince)
+	}
+	return window
+}
+
+// queuedIDs lê os IDs de abastecimentos e vendas que estão na fila de reenvio, por produtor
+// e entidade. A fila é lida uma vez por job e o índice é compartilhado (só para leitura)
+// pelos produtores. Uma falha ao ler a fila só é logada: na pior das hipóteses, os registros
+// chegam duas vezes ao Agriwin.
+func (s *ImporterService) queuedIDs(ctx context.Context) map[int]map[string]map[int]time.Time {
+	if s.deadLetters == nil {
+		return nil
+	}
+	entries, err := s.deadLetters.List(ctx)
+	if err != nil {
+		log.Printf("Warning: could not read the dead-letter queue, queued records may be sent again: %v", err)
+		return nil
+	}
+	queued := make(map[int]map[string]map[int]time.Time)
+	add := func(produtorID int, entity string, id int, date time.Time) {
+		if queued[produtorID] == nil {
+			queued[produtorID] = make(map[string]map[int]time.Time)
+		}
+		if queued[produtorID][entity] == nil {
+			queued[produtorID][entity] = make(map[int]time.Time)
+		}
+		queued[produtorID][entity][id] = date
+	}
+	for _, entry := range entries {
+		for _, supply := range entry.Payload.Supplies {
+			add(entry.ProdutorID, dto.EntitySupplies, supply.ID, supply.Date.Time)
+		}
+		for _, sale := range entry.Payload.ProductSales {
+			add(entry.ProdutorID, dto.EntityProductSales, sale.ID, sale.Date.Time)
+		}
+	}
+	return queued
+}
+
+// fixedWindow cria a janela de uma busca avulsa, sem checkpoint prévio e sem gravá-lo.
+func fixedWindow(produtorID int, entity string, fetch dto.TimeRange) *entityWindow {
+	return &entityWindow{
+		fetch:      fetch,
+		checkpoint: dto.Checkpoint{ProdutorID: produtorID, Entity: entity},
+		batch:      make(map[int]time.Time),
+	}
+}
+
+// seen indica se o registro já foi entregue (em uma execução anterior ou em um lote
+// anterior desta), se está na fila de reenvio ou se já está no lote atual.
+func (w *entityWindow) seen(id int) bool {
+	if _, ok := w.checkpoint.SeenIDs[id]; ok {
+		return true
+	}
+	if _, ok := w.queued[id]; ok {
+		return true
+	}
+	_, ok := w.batch[id]
+	return ok
+}
+
+// add registra um registro que entrou no lote atual.
+func (w *entityWindow) add(date time.Time, id int) {
+	w.batch[id] = date
+	if date.After(w.latest) {
+		w.latest = date
+	}
+}
+
+// hold segura o checkpoint antes de um registro inválido. Os registros chegam ordenados
+// por data, então tudo o que veio antes dele é mais antigo.
+func (w *entityWindow) hold() {
+	if w.held {
+		return
+	}
+	w.held = true
+	w.holdAt = w.latest
+	if w.holdAt.IsZero() {
+		w.holdAt = w.checkpoint.LastTimestamp
+	}
+}
+
+// committed devolve o checkpoint da janela com o lote atual incorporado, sem alterar a
+// janela. IDs mais antigos que a sobreposição (ou que o limite de FetchSince, quando o
+// checkpoint está seguro) são descartados, pois nunca mais voltarão em uma busca.
+func (s *ImporterService) committed(window *entityWindow) dto.Checkpoint {
+	cp := window.checkpoint
+	cp.SeenIDs = make(map[int]time.Time, len(window.checkpoint.SeenIDs)+len(window.batch))
+	maps.Copy(cp.SeenIDs, window.checkpoint.SeenIDs)
+	for id, date := range window.batch {
+		if !window.held || !date.After(window.holdAt) {
+			cp.Advance(date, id)
+		}
+		cp.SeenIDs[id] = date
+	}
+	horizon := cp.LastTimestamp.Add(-s.opts.FetchOverlap)
+	if floor := window.fetch.Until.Add(-s.opts.FetchSince); window.persist && floor.After(horizon) {
+		horizon = floor
+	}
+	cp.Prune(horizon)
+	cp.UpdatedAt = time.Now()
+	return cp
+}
+
+// commit incorpora o lote aceito pelo Agriwin ao checkpoint e o grava.
+// Uma falha ao gravar só é logada: os dados já foram entregues.
+func (s *ImporterService) commit(ctx context.Context, logger *log.Logger, window *entityWindow) {
+	if len(window.batch) == 0 {
+		return
+	}
+	window.checkpoint = s.committed(window)
+	for id := range window.batch {
+		window.deliveredAck.Count++
+		window.deliveredAck.HighestID = max(window.deliveredAck.HighestID, id)
+	}
+	window.batch = make(map[int]time.Time)
+
+	if s.checkpoints == nil || !window.persist {
+		return
+	}
+	if err := s.checkpoints.Save(ctx, window.checkpoint); err != nil {
+		logger.Printf("Warning: failed to save %s checkpoint: %v", window.checkpoint.Entity, err)
+	}
+}
+
+// confirmedUntil é até onde a janela da entidade foi importada por completo: until, ou o
+// ponto em que o checkpoint ficou seguro antes de um registro inválido.
+func (w *entityWindow) confirmedUntil(until time.Time) time.Time {
+	if !w.held {
+		return until
+	}
+	if w.holdAt.Before(w.fetch.Since) {
+		return w.fetch.Since
+	}
+	return w.holdAt
+}
+
+// ack monta o resumo do que foi entregue da entidade, para a confirmação ao Agriwin.
+func (w *entityWindow) ack() dto.EntityAck {
+	ack := w.deliveredAck
+	ack.Skipped = w.skipped
+	if !w.checkpoint.LastTimestamp.IsZero() {
+		last := w.checkpoint.LastTimestamp
+		ack.LastTimestamp = &last
+	}
+	return ack
+}
+
+// status é o status da entidade transacional no payload. Falhas de busca interrompem o
+// produtor, então uma entidade selecionada está sempre ok no payload enviado.
+func (w *entityWindow) status() dto.EntityStatus {
+	if w.skipped {
+		return dto.EntityStatus{Status: dto.EntitySkipped}
+	}
+	return dto.EntityStatus{Status: dto.EntityOK}
+}
+
+func isTransactional(entity string) bool {
+	return entity == dto.EntitySupplies || entity == dto.EntityProductSales
+}
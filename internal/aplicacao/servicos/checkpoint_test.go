@@ -0,0 +1,133 @@
+package servicos
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"vestro/internal/dto"
+)
+
+func runOnce(t *testing.T, importer *ImporterService) dto.ProducerReport {
+	t.Helper()
+	report, err := importer.RunImport(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("RunImport error: %v", err)
+	}
+	if len(report.Producers) != 1 {
+		t.Fatalf("report has %d producers, want 1", len(report.Producers))
+	}
+	return report.Producers[0]
+}
+
+func TestInvalidRecordHoldsTheCheckpoint(t *testing.T) {
+	valid, invalid, after := ago(90*time.Minute), ago(80*time.Minute), ago(70*time.Minute)
+	vestro := &fakeVestro{}
+	vestro.setSupplies(
+		vestroRecord{id: 1, date: valid},
+		vestroRecord{id: 2, date: invalid, invalid: true},
+		vestroRecord{id: 3, date: after},
+	)
+	agriwin := &fakeAgriwin{}
+	checkpoints := newMemoryCheckpoints()
+	importer := newTestImporter(vestro, agriwin, checkpoints, nil, Options{FetchOverlap: 30 * time.Minute})
+
+	result := runOnce(t, importer)
+	if result.Status != dto.StatusPartial || len(result.InvalidRecords) != 1 {
+		t.Fatalf("status = %s with %d invalid record(s), want partial with 1", result.Status, len(result.InvalidRecords))
+	}
+	cp, _, _ := checkpoints.Load(context.Background(), 7, dto.EntitySupplies)
+	if !cp.LastTimestamp.Equal(valid) {
+		t.Errorf("checkpoint = %s, want it held at the last valid record %s", cp.LastTimestamp, valid)
+	}
+	if _, ok := cp.SeenIDs[3]; !ok {
+		t.Errorf("seen ids = %v, want the record delivered after the invalid one", cp.SeenIDs)
+	}
+	if ack := agriwin.acks[0]; !ack.WindowEnd.Equal(valid) {
+		t.Errorf("acknowledged window end = %s, want the hold point %s", ack.WindowEnd, valid)
+	}
+
+	// A próxima execução busca o registro inválido de novo, sem repetir os entregues
+	runOnce(t, importer)
+	if got := agriwin.sentSupplies(); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("delivered supplies = %v, want [1 3]", got)
+	}
+}
+
+func TestInvalidFirstRecordKeepsSeenIDs(t *testing.T) {
+	vestro := &fakeVestro{}
+	vestro.setSupplies(
+		vestroRecord{id: 1, date: ago(90 * time.Minute), invalid: true},
+		vestroRecord{id: 2, date: ago(80 * time.Minute)},
+	)
+	agriwin := &fakeAgriwin{}
+	checkpoints := newMemoryCheckpoints()
+	importer := newTestImporter(vestro, agriwin, checkpoints, nil, Options{FetchOverlap: 30 * time.Minute})
+
+	runOnce(t, importer)
+	cp, ok, _ := checkpoints.Load(context.Background(), 7, dto.EntitySupplies)
+	if !ok || !cp.LastTimestamp.IsZero() {
+		t.Fatalf("checkpoint = %+v (saved %t), want one without a timestamp", cp, ok)
+	}
+	runOnce(t, importer)
+	if got := agriwin.sentSupplies(); !slices.Equal(got, []int{2}) {
+		t.Errorf("delivered supplies = %v, want [2] only once", got)
+	}
+}
+
+func TestHoldIsBoundedByFetchSince(t *testing.T) {
+	vestro := &fakeVestro{}
+	vestro.setSupplies(
+		vestroRecord{id: 1, date: ago(100 * time.Minute)},
+		vestroRecord{id: 2, date: ago(90 * time.Minute), invalid: true},
+		vestroRecord{id: 3, date: ago(80 * time.Minute)},
+	)
+	agriwin := &fakeAgriwin{}
+	checkpoints := newMemoryCheckpoints()
+	runOnce(t, newTestImporter(vestro, agriwin, checkpoints, nil, Options{FetchOverlap: 30 * time.Minute}))
+
+	// Com o registro inválido fora de FetchSince, a janela não volta mais até ele
+	vestro.setSupplies(
+		vestroRecord{id: 2, date: ago(90 * time.Minute), invalid: true},
+		vestroRecord{id: 3, date: ago(80 * time.Minute)},
+		vestroRecord{id: 4, date: ago(10 * time.Minute)},
+	)
+	result := runOnce(t, newTestImporter(vestro, agriwin, checkpoints, nil, Options{FetchSince: 85 * time.Minute, FetchOverlap: 30 * time.Minute}))
+	if result.Status != dto.StatusOK {
+		t.Fatalf("status = %s, want ok once the invalid record left the window", result.Status)
+	}
+	cp, _, _ := checkpoints.Load(context.Background(), 7, dto.EntitySupplies)
+	if cp.LastID != 4 {
+		t.Errorf("checkpoint at id %d, want 4", cp.LastID)
+	}
+	if _, ok := cp.SeenIDs[1]; ok {
+		t.Errorf("seen ids = %v, want the ids before FetchSince pruned", cp.SeenIDs)
+	}
+	if got := agriwin.sentSupplies(); !slices.Equal(got, []int{1, 3, 4}) {
+		t.Errorf("delivered supplies = %v, want [1 3 4]", got)
+	}
+}
+
+func TestOverlapDropsSeenIDs(t *testing.T) {
+	vestro := &fakeVestro{}
+	vestro.setSupplies(vestroRecord{id: 1, date: ago(90 * time.Minute)}, vestroRecord{id: 2, date: ago(80 * time.Minute)})
+	agriwin := &fakeAgriwin{}
+	importer := newTestImporter(vestro, agriwin, newMemoryCheckpoints(), nil, Options{FetchOverlap: 30 * time.Minute})
+	runOnce(t, importer)
+
+	// Um registro lançado com atraso, com data dentro da sobreposição, chega; os já
+	// entregues voltam na busca e são descartados
+	vestro.setSupplies(
+		vestroRecord{id: 1, date: ago(90 * time.Minute)},
+		vestroRecord{id: 4, date: ago(85 * time.Minute)},
+		vestroRecord{id: 2, date: ago(80 * time.Minute)},
+		vestroRecord{id: 3, date: ago(70 * time.Minute)},
+	)
+	result := runOnce(t, importer)
+	if got := agriwin.sentSupplies(); !slices.Equal(got, []int{1, 2, 4, 3}) {
+		t.Errorf("delivered supplies = %v, want [1 2 4 3]", got)
+	}
+	if result.Records[dto.EntitySupplies] != 2 {
+		t.Errorf("supplies reported = %d, want 2", result.Records[dto.EntitySupplies])
+	}
+}
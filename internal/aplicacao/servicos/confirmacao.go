@@ -0,0 +1,38 @@
+package servicos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+	"vestro/internal/dto"
+)
+
+// acknowledge confirma ao Agriwin a janela importada. Uma falha aqui não invalida o envio
+// (os dados já foram entregues), então é apenas logada e indicada no relatório.
+func (s *ImporterService) acknowledge(ctx context.Context, logger *log.Logger, ack dto.SyncAcknowledgement) bool {
+	if s.acknowledger == nil {
+		return false
+	}
+	if err := s.acknowledger.Acknowledge(ctx, ack); err != nil {
+		logger.Printf("Warning: failed to acknowledge window %s - %s to Agriwin: %v", ack.WindowStart.Format(time.RFC3339), ack.WindowEnd.Format(time.RFC3339), err)
+		return false
+	}
+	logger.Printf("Acknowledged window %s - %s to Agriwin (%d supplies, %d product sales).", ack.WindowStart.Format(time.RFC3339), ack.WindowEnd.Format(time.RFC3339), ack.Supplies.Count, ack.ProductSales.Count)
+	return true
+}
+
+// newRunID gera um identificador aleatório para a execução do job.
+func newRunID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+func earliest(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,109 @@
+package servicos
+
+import (
+	"context"
+	"log"
+	"vestro/internal/aplicacao/portas"
+	"vestro/internal/dto"
+)
+
+// dropUnchanged tira do payload as listas de dados mestres cujo hash é igual ao do último
+// envio entregue ao Agriwin e as marca como unchanged. Devolve os hashes das listas que
+// ficaram, para serem gravados quando o envio que as leva for aceito.
+// Sem hash salvo (ou com falha ao lê-lo), a lista vai completa.
+func (s *ImporterService) dropUnchanged(ctx context.Context, logger *log.Logger, user dto.UserToIntegrate, payload *dto.IntegrationPayload, hashes map[string]string) map[dto.MasterDataKey]string {
+	if s.masterHashes == nil {
+		return nil
+	}
+	changed := make(map[dto.MasterDataKey]string)
+	for entity, hash := range hashes {
+		key := dto.MasterDataKey{ProdutorID: user.ProdutorID, Account: user.Login, Entity: entity}
+		saved, ok, err := s.masterHashes.Load(ctx, key)
+		if err != nil {
+			logger.Printf("Warning: could not load the %s hash, sending the full list: %v", entity, err)
+		}
+		if ok && saved == hash {
+			payload.Omit(entity)
+			payload.EntityStatus[entity] = dto.EntityStatus{Status: dto.EntityUnchanged}
+			continue
+		}
+		changed[key] = hash
+	}
+	if unchanged := len(hashes) - len(changed); unchanged > 0 {
+		logger.Printf("Leaving out %d master data list(s) unchanged since the last delivery.", unchanged)
+	}
+	return changed
+}
+
+// detectChanges compara veículos, motoristas e funcionários com os snapshots anteriores do
+// produtor e coloca as mudanças no payload. Devolve os novos snapshots que têm mudanças, a
+// serem gravados com a entrega. O primeiro snapshot de uma lista é só a base de comparação:
+// não gera mudanças e é gravado na hora.
+func (s *ImporterService) detectChanges(ctx context.Context, logger *log.Logger, payload *dto.IntegrationPayload) []dto.Snapshot {
+	if s.snapshots == nil {
+		return nil
+	}
+	var pending []dto.Snapshot
+	for _, snap := range []*dto.Snapshot{
+		trackChanges(ctx, logger, s.snapshots, payload, dto.EntityVehicles, payload.Vehicles),
+		trackChanges(ctx, logger, s.snapshots, payload, dto.EntityDrivers, payload.Drivers),
+		trackChanges(ctx, logger, s.snapshots, payload, dto.EntityEmployees, payload.Employees),
+	} {
+		if snap != nil {
+			pending = append(pending, *snap)
+		}
+	}
+	return pending
+}
+
+// trackChanges faz a comparação de uma lista. Listas que não foram buscadas (nil) ficam
+// como estão.
+func trackChanges[T dto.Tracked](ctx context.Context, logger *log.Logger, store portas.SnapshotStore, payload *dto.IntegrationPayload, entity string, list []T) *dto.Snapshot {
+	if list == nil {
+		return nil
+	}
+	previous, ok, err := store.Load(ctx, payload.ProdutorID, entity)
+	if err != nil {
+		// Sem o snapshot anterior não há como comparar; a lista segue sem mudanças
+		logger.Printf("Warning: could not load the %s snapshot, skipping change detection: %v", entity, err)
+		return nil
+	}
+	if !ok {
+		previous = dto.Snapshot{ProdutorID: payload.ProdutorID, Entity: entity}
+	}
+
+	changes, next, err := dto.Diff(previous, list)
+	if err != nil {
+		logger.Printf("Warning: could not compare %s with the last snapshot: %v", entity, err)
+		return nil
+	}
+	if !ok {
+		logger.Printf("Taking the first %s snapshot (%d records).", entity, len(next.Records))
+		if err := store.Save(ctx, next); err != nil {
+			logger.Printf("Warning: failed to save the %s snapshot: %v", entity, err)
+		}
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	logger.Printf("Detected %d %s change(s) since the last snapshot.", len(changes), entity)
+	payload.Changes = append(payload.Changes, changes...)
+	return &next
+}
+
+// saveMasterData grava os hashes e os snapshots do primeiro envio depois que ele foi entregue.
+// Uma falha ao gravar só é logada: no pior caso, a lista (e as suas mudanças) vão de novo.
+func (s *ImporterService) saveMasterData(ctx context.Context, logger *log.Logger, d *delivery) {
+	for key, hash := range d.masterHashes {
+		if err := s.masterHashes.Save(ctx, key, hash); err != nil {
+			logger.Printf("Warning: failed to save the %s hash: %v", key.Entity, err)
+		}
+	}
+	for _, snap := range d.snapshots {
+		if err := s.snapshots.Save(ctx, snap); err != nil {
+			logger.Printf("Warning: failed to save the %s snapshot: %v", snap.Entity, err)
+		}
+	}
+	d.masterHashes, d.snapshots = nil, nil
+}
@@ -0,0 +1,47 @@
+package servicos
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"vestro/internal/dto"
+)
+
+// memoryHashes é um MasterDataHashes em memória.
+type memoryHashes struct {
+	mu     sync.Mutex
+	hashes map[dto.MasterDataKey]string
+}
+
+func (m *memoryHashes) Load(_ context.Context, key dto.MasterDataKey) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, ok := m.hashes[key]
+	return hash, ok, nil
+}
+
+func (m *memoryHashes) Save(_ context.Context, key dto.MasterDataKey, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hashes[key] = hash
+	return nil
+}
+
+func TestChangedMasterDataIsSentAlone(t *testing.T) {
+	entities, _ := dto.ParseEntitySet(dto.EntitySupplies + "," + dto.EntityProducts)
+	agriwin := &fakeAgriwin{}
+	importer := newTestImporter(&fakeVestro{}, agriwin, nil, nil, Options{Entities: entities})
+	importer.masterHashes = &memoryHashes{hashes: make(map[dto.MasterDataKey]string)}
+
+	// Sem abastecimentos, a lista de produtos ainda não entregue vai sozinha
+	runOnce(t, importer)
+	if len(agriwin.sent) != 1 || agriwin.sent[0].Products == nil {
+		t.Fatalf("sent %d payload(s), want one with the products", len(agriwin.sent))
+	}
+
+	// Sem mudança, não há o que enviar
+	runOnce(t, importer)
+	if len(agriwin.sent) != 1 {
+		t.Errorf("sent %d payload(s) after an unchanged run, want none more", len(agriwin.sent)-1)
+	}
+}
@@ -0,0 +1,114 @@
+package servicos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"vestro/internal/dto"
+)
+
+// errDeadLettered indica que um envio falhou mas foi guardado na fila de reenvio.
+var errDeadLettered = errors.New("payload saved to the dead-letter queue")
+
+// delivery numera os envios de um produtor em uma execução.
+type delivery struct {
+	id       string
+	runID    string
+	sequence int
+
+	// Janela buscada, guardada junto dos envios que falharem
+	windowStart time.Time
+	windowEnd   time.Time
+
+	// Hashes das listas de dados mestres e snapshots com mudanças do primeiro envio, gravados
+	// quando ele é aceito ou guardado na fila de reenvio
+	masterHashes map[dto.MasterDataKey]string
+	snapshots    []dto.Snapshot
+
+	// Checkpoints que o lote em envio grava ao ser aceito, guardados com ele se for para a
+	// fila de reenvio
+	checkpoints []dto.Checkpoint
+}
+
+// newDelivery começa um conjunto de envios. A janela guardada nos envios que falharem vai do
+// início mais antigo das duas entidades até end.
+func newDelivery(runID, id string, supplies, sales *entityWindow, end time.Time) *delivery {
+	return &delivery{
+		id:          id,
+		runID:       runID,
+		windowStart: earliest(supplies.fetch.Since, sales.fetch.Since),
+		windowEnd:   end,
+	}
+}
+
+// sendBatch divide o lote em partes de até MaxChunkBytes e as envia em ordem, todas com o seu
+// número de sequência e o total de envios até o fim do lote. Quando last é true, a última
+// parte fecha o conjunto. Se uma parte falhar, ela e as seguintes vão para a fila de reenvio.
+func (s *ImporterService) sendBatch(ctx context.Context, logger *log.Logger, d *delivery, payload dto.IntegrationPayload, last bool) error {
+	chunks := payload.Split(s.opts.MaxChunkBytes)
+	total := d.sequence + len(chunks)
+	for i := range chunks {
+		d.sequence++
+		chunks[i].Batch = &dto.BatchInfo{DeliveryID: d.id, Sequence: d.sequence, Total: total}
+	}
+	if last {
+		closeDelivery(chunks)
+	}
+
+	for i, chunk := range chunks {
+		logger.Printf("Sending chunk %d for producer %d to Agriwin (%d supplies, %d product sales, last=%t)...", chunk.Batch.Sequence, chunk.ProdutorID, len(chunk.Supplies), len(chunk.ProductSales), chunk.Batch.Last)
+		// Um envio já iniciado não é cortado no meio por um desligamento: ele termina (ou falha
+		// pelo timeout do próprio notifier) e o job para antes da próxima busca.
+		if err := s.notifier.Send(context.WithoutCancel(ctx), chunk); err != nil {
+			err = fmt.Errorf("%w (chunk %d): %w", errSendFailed, chunk.Batch.Sequence, err)
+			return s.deadLetter(ctx, logger, d, chunks[i:], err)
+		}
+		s.saveMasterData(ctx, logger, d)
+	}
+	return nil
+}
+
+// deadLetter guarda as partes não enviadas na fila de reenvio. Como o produtor para por aqui,
+// a última delas passa a fechar o conjunto, para que o Agriwin o veja completo após o replay.
+// Retorna sendErr, acrescido de errDeadLettered se todas as partes foram guardadas.
+func (s *ImporterService) deadLetter(ctx context.Context, logger *log.Logger, d *delivery, pending []dto.IntegrationPayload, sendErr error) error {
+	if s.deadLetters == nil {
+		return sendErr
+	}
+	closeDelivery(pending)
+
+	now := time.Now()
+	for i, chunk := range pending {
+		entry := dto.DeadLetter{
+			ID:            fmt.Sprintf("%s-%03d", d.id, chunk.Batch.Sequence),
+			RunID:         d.runID,
+			ProdutorID:    chunk.ProdutorID,
+			WindowStart:   d.windowStart,
+			WindowEnd:     d.windowEnd,
+			Error:         sendErr.Error(),
+			Attempts:      1,
+			FailedAt:      now,
+			LastAttemptAt: now,
+			Payload:       chunk,
+		}
+		if i == len(pending)-1 {
+			entry.Checkpoints = d.checkpoints
+		}
+		if err := s.deadLetters.Put(ctx, entry); err != nil {
+			logger.Printf("ERROR: Failed to save chunk %d to the dead-letter queue: %v", chunk.Batch.Sequence, err)
+			return sendErr
+		}
+	}
+	logger.Printf("Saved %d chunk(s) to the dead-letter queue for replay.", len(pending))
+	// Os dados mestres chegam ao Agriwin pelo replay; não são mandados de novo
+	s.saveMasterData(ctx, logger, d)
+	return fmt.Errorf("%w; %w", sendErr, errDeadLettered)
+}
+
+// closeDelivery marca a última parte como a que fecha o conjunto de envios. As partes são
+// sempre as últimas do conjunto, então o Total delas já é o final.
+func closeDelivery(chunks []dto.IntegrationPayload) {
+	chunks[len(chunks)-1].Batch.Last = true
+}
@@ -0,0 +1,55 @@
+package servicos
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEveryChunkCarriesTheTotal(t *testing.T) {
+	vestro := &fakeVestro{}
+	for i := range 5 {
+		vestro.supplies = append(vestro.supplies, vestroRecord{id: i + 1, date: ago(time.Duration(90-i) * time.Minute)})
+	}
+	agriwin := &fakeAgriwin{}
+	// Lotes de dois registros, cada registro em uma parte; os dados mestres vão sozinhos na
+	// primeira parte do primeiro lote
+	importer := newTestImporter(vestro, agriwin, nil, nil, Options{BatchSize: 2, MaxChunkBytes: 1})
+	runOnce(t, importer)
+
+	var got []string
+	for _, payload := range agriwin.sent {
+		batch := payload.Batch
+		got = append(got, fmt.Sprintf("%d/%d/%t", batch.Sequence, batch.Total, batch.Last))
+	}
+	want := []string{"1/3/false", "2/3/false", "3/3/false", "4/5/false", "5/5/false", "6/6/true"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sequence/total/last = %v, want %v", got, want)
+	}
+}
+
+func TestDeadLetteredChunksCloseTheDelivery(t *testing.T) {
+	vestro := &fakeVestro{}
+	vestro.setSupplies(
+		vestroRecord{id: 1, date: ago(90 * time.Minute)},
+		vestroRecord{id: 2, date: ago(80 * time.Minute)},
+		vestroRecord{id: 3, date: ago(70 * time.Minute)},
+	)
+	agriwin := &fakeAgriwin{failing: true}
+	deadLetters := newMemoryDeadLetters()
+	importer := newTestImporter(vestro, agriwin, nil, deadLetters, Options{BatchSize: 10, MaxChunkBytes: 1})
+	runOnce(t, importer)
+
+	entries, _ := deadLetters.List(context.Background())
+	var got []string
+	for _, entry := range entries {
+		batch := entry.Payload.Batch
+		got = append(got, fmt.Sprintf("%d/%d/%t", batch.Sequence, batch.Total, batch.Last))
+	}
+	want := []string{"1/4/false", "2/4/false", "3/4/false", "4/4/true"}
+	if !slices.Equal(got, want) {
+		t.Errorf("queued sequence/total/last = %v, want %v", got, want)
+	}
+}
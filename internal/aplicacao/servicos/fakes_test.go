@@ -0,0 +1,214 @@
+package servicos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"vestro/internal/aplicacao/portas"
+	"vestro/internal/dto"
+)
+
+// vestroRecord é um abastecimento devolvido pela sessão falsa; com invalid, ele chega como
+// *dto.RecordError na sua posição.
+type vestroRecord struct {
+	id      int
+	date    time.Time
+	invalid bool
+}
+
+// fakeVestro faz o papel da API Vestro: todo login recebe a mesma sessão, que devolve os
+// abastecimentos da janela pedida, em ordem de data. Os dados mestres vêm vazios.
+type fakeVestro struct {
+	mu       sync.Mutex
+	supplies []vestroRecord
+}
+
+func (v *fakeVestro) setSupplies(records ...vestroRecord) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.supplies = records
+}
+
+func (v *fakeVestro) Authenticate(context.Context, string, string) (portas.VestroSession, error) {
+	return v, nil
+}
+
+func (v *fakeVestro) GetSupplies(context.Context, dto.TimeRange, dto.TransactionFilter) ([]dto.Supply, error) {
+	return nil, errors.New("not used")
+}
+
+func (v *fakeVestro) GetProductSales(context.Context, dto.TimeRange, dto.TransactionFilter) ([]dto.ProductSale, error) {
+	return nil, errors.New("not used")
+}
+
+func (v *fakeVestro) StreamSupplies(_ context.Context, window dto.TimeRange, _ dto.TransactionFilter) iter.Seq2[dto.Supply, error] {
+	v.mu.Lock()
+	records := slices.Clone(v.supplies)
+	v.mu.Unlock()
+	return func(yield func(dto.Supply, error) bool) {
+		for _, r := range records {
+			if r.date.Before(window.Since) || !r.date.Before(window.Until) {
+				continue
+			}
+			if r.invalid {
+				if !yield(dto.Supply{}, &dto.RecordError{ID: "x", Err: errors.New("bad volume")}) {
+					return
+				}
+				continue
+			}
+			if !yield(dto.Supply{ID: r.id, Date: dto.VestroTime{Time: r.date}}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (v *fakeVestro) StreamProductSales(context.Context, dto.TimeRange, dto.TransactionFilter) iter.Seq2[dto.ProductSale, error] {
+	return func(func(dto.ProductSale, error) bool) {}
+}
+
+func (v *fakeVestro) GetProducts(context.Context) ([]dto.Product, error)   { return nil, nil }
+func (v *fakeVestro) GetFuelTypes(context.Context) ([]dto.FuelType, error) { return nil, nil }
+func (v *fakeVestro) GetVehicles(context.Context) ([]dto.Vehicle, error)   { return nil, nil }
+func (v *fakeVestro) GetDrivers(context.Context) ([]dto.Driver, error)     { return nil, nil }
+func (v *fakeVestro) GetEmployees(context.Context) ([]dto.Employee, error) { return nil, nil }
+
+// fakeAgriwin recebe os envios, as confirmações e fornece a lista de produtores. Com
+// failing, todo envio falha.
+type fakeAgriwin struct {
+	mu      sync.Mutex
+	users   []dto.UserToIntegrate
+	failing bool
+	sent    []dto.IntegrationPayload
+	acks    []dto.SyncAcknowledgement
+}
+
+func (a *fakeAgriwin) GetUsersToIntegrate(context.Context) ([]dto.UserToIntegrate, error) {
+	return a.users, nil
+}
+
+func (a *fakeAgriwin) Send(_ context.Context, payload dto.IntegrationPayload) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failing {
+		return errors.New("agriwin is down")
+	}
+	a.sent = append(a.sent, payload)
+	return nil
+}
+
+func (a *fakeAgriwin) Acknowledge(_ context.Context, ack dto.SyncAcknowledgement) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acks = append(a.acks, ack)
+	return nil
+}
+
+// sentSupplies devolve os IDs de abastecimentos entregues, na ordem de envio.
+func (a *fakeAgriwin) sentSupplies() []int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var ids []int
+	for _, payload := range a.sent {
+		for _, supply := range payload.Supplies {
+			ids = append(ids, supply.ID)
+		}
+	}
+	return ids
+}
+
+// memoryCheckpoints é um CheckpointStore em memória.
+type memoryCheckpoints struct {
+	mu          sync.Mutex
+	checkpoints map[string]dto.Checkpoint
+}
+
+func newMemoryCheckpoints() *memoryCheckpoints {
+	return &memoryCheckpoints{checkpoints: make(map[string]dto.Checkpoint)}
+}
+
+func (m *memoryCheckpoints) Load(_ context.Context, produtorID int, entity string) (dto.Checkpoint, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp, ok := m.checkpoints[checkpointKey(produtorID, entity)]
+	return cp, ok, nil
+}
+
+func (m *memoryCheckpoints) Save(_ context.Context, cp dto.Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[checkpointKey(cp.ProdutorID, cp.Entity)] = cp
+	return nil
+}
+
+func (m *memoryCheckpoints) Close() error { return nil }
+
+func checkpointKey(produtorID int, entity string) string {
+	return fmt.Sprintf("%d/%s", produtorID, entity)
+}
+
+// memoryDeadLetters é uma DeadLetterQueue em memória; List conta as leituras.
+type memoryDeadLetters struct {
+	mu      sync.Mutex
+	entries map[string]dto.DeadLetter
+	lists   int
+}
+
+func newMemoryDeadLetters() *memoryDeadLetters {
+	return &memoryDeadLetters{entries: make(map[string]dto.DeadLetter)}
+}
+
+func (m *memoryDeadLetters) Put(_ context.Context, entry dto.DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[entry.ID] = entry
+	return nil
+}
+
+func (m *memoryDeadLetters) List(context.Context) ([]dto.DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lists++
+	var entries []dto.DeadLetter
+	for _, entry := range m.entries {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b dto.DeadLetter) int { return strings.Compare(a.ID, b.ID) })
+	return entries, nil
+}
+
+func (m *memoryDeadLetters) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, id)
+	return nil
+}
+
+// testUser é o produtor usado nos testes, com a última sincronização duas horas atrás.
+func testUser() dto.UserToIntegrate {
+	return dto.UserToIntegrate{ProdutorID: 7, Login: "produtor7", Senha: "secret", Data: time.Now().Add(-2 * time.Hour)}
+}
+
+// newTestImporter cria o serviço com os fakes, só com abastecimentos selecionados.
+func newTestImporter(vestro *fakeVestro, agriwin *fakeAgriwin, checkpoints portas.CheckpointStore, deadLetters portas.DeadLetterQueue, opts Options) *ImporterService {
+	if opts.FetchSince == 0 {
+		opts.FetchSince = 3 * time.Hour
+	}
+	if opts.Entities == nil {
+		opts.Entities, _ = dto.ParseEntitySet(dto.EntitySupplies)
+	}
+	if agriwin.users == nil {
+		agriwin.users = []dto.UserToIntegrate{testUser()}
+	}
+	return New(vestro, agriwin, agriwin, agriwin, checkpoints, deadLetters, nil, nil, opts)
+}
+
+// ago devolve o instante d antes de agora, sem a parte de nanossegundos.
+func ago(d time.Duration) time.Time {
+	return time.Now().Add(-d).Truncate(time.Second)
+}
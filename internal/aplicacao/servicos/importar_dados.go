@@ -2,165 +2,513 @@ package servicos
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 	"vestro/internal/aplicacao/portas"
 	"vestro/internal/dto"
 )
 
+// Options agrupa as configurações do serviço de importação.
+type Options struct {
+	// FetchSince limita o histórico buscado quando a última sincronização é muito antiga.
+	FetchSince time.Duration
+	// FetchOverlap recua o início da janela retomada de um checkpoint, para pegar registros
+	// lançados com atraso na Vestro; sem checkpoint não há IDs vistos e a janela não recua.
+	FetchOverlap time.Duration
+	// BatchSize é a quantidade de registros transacionais acumulados antes de cada envio ao Agriwin.
+	BatchSize int
+	// DefaultFilterMode é o filtro Vestro usado para produtores que não definem o seu.
+	DefaultFilterMode dto.FilterMode
+	// Entities são as entidades buscadas na execução (nil = todas). Cada produtor pode
+	// restringir essa seleção com o campo entidades.
+	Entities dto.EntitySet
+	// FetchPolicy define se a falha de uma lista de dados mestres derruba o produtor
+	// (fail_fast) ou se o envio segue sem ela (partial).
+	FetchPolicy dto.FetchPolicy
+	// MaxChunkBytes limita o tamanho (em bytes de JSON) de cada envio ao Agriwin. 0 = sem limite.
+	MaxChunkBytes int
+	// ProducerConcurrency é quantos produtores são processados ao mesmo tempo.
+	ProducerConcurrency int
+	// JobTimeout e ProducerTimeout limitam a duração do job inteiro e de cada produtor. 0 = sem prazo.
+	JobTimeout      time.Duration
+	ProducerTimeout time.Duration
+}
+
 type ImporterService struct {
 	apiClient    portas.VestroAPIClient
 	notifier     portas.Notifier
 	userProvider portas.UserProvider
-	fetchSince   time.Duration
+	acknowledger portas.SyncAcknowledger
+	checkpoints  portas.CheckpointStore
+	deadLetters  portas.DeadLetterQueue
+	masterHashes portas.MasterDataHashes
+	snapshots    portas.SnapshotStore
+	opts         Options
 }
 
+// New cria o serviço de importação. acknowledger, checkpoints, deadLetters, masterHashes e
+// snapshots são opcionais: com nil, a janela importada não é confirmada ao Agriwin, a janela
+// de busca vem apenas da data informada por ele, os envios que falharem não são guardados
+// para reenvio, os dados mestres vão completos em toda execução e as mudanças de registros
+// não são detectadas.
 func New(
 	apiClient portas.VestroAPIClient,
 	notifier portas.Notifier,
 	userProvider portas.UserProvider,
-	fetchSince time.Duration,
+	acknowledger portas.SyncAcknowledger,
+	checkpoints portas.CheckpointStore,
+	deadLetters portas.DeadLetterQueue,
+	masterHashes portas.MasterDataHashes,
+	snapshots portas.SnapshotStore,
+	opts Options,
 ) *ImporterService {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	if opts.ProducerConcurrency <= 0 {
+		opts.ProducerConcurrency = 1
+	}
+	if opts.DefaultFilterMode == "" {
+		opts.DefaultFilterMode = dto.FilterByDriver
+	}
+	if opts.FetchPolicy == "" {
+		opts.FetchPolicy = dto.FetchFailFast
+	}
 	return &ImporterService{
 		apiClient:    apiClient,
 		notifier:     notifier,
 		userProvider: userProvider,
-		fetchSince:   fetchSince,
+		acknowledger: acknowledger,
+		checkpoints:  checkpoints,
+		deadLetters:  deadLetters,
+		masterHashes: masterHashes,
+		snapshots:    snapshots,
+		opts:         opts,
 	}
 }
 
-func (s *ImporterService) RunImport(ctx context.Context) error {
+// errSendFailed marca os erros de envio ao Agriwin, para diferenciá-los das falhas de busca no relatório.
+var errSendFailed = errors.New("failed to send data to Agriwin")
+
+// RunImport processa todos os produtores e devolve o relatório da execução. O erro só é
+// preenchido quando o job inteiro não pôde rodar; falhas por produtor ficam no relatório.
+// Quando stop é fechado (no desligamento do modo serve), os produtores em andamento terminam
+// e os que ainda não começaram ficam no relatório como not_started. stop pode ser nil.
+// Quando ctx é cancelado (ou o prazo do job acaba), as buscas em andamento são interrompidas,
+// os produtores afetados ficam como interrupted e o relatório registra o motivo.
+func (s *ImporterService) RunImport(ctx context.Context, stop <-chan struct{}) (*dto.JobReport, error) {
 	log.Println("Starting Vestro data import job...")
+	report := &dto.JobReport{RunID: newRunID(), StartedAt: time.Now()}
+	log.Printf("Run ID: %s", report.RunID)
+
+	ctx, cancel := withTimeout(ctx, s.opts.JobTimeout, errJobTimeout)
+	defer cancel()
 
 	// 1. Buscar produtores a processar da API Agriwin
 	log.Println("Fetching users to integrate from Agriwin...")
 	users, err := s.userProvider.GetUsersToIntegrate(ctx)
 	if err != nil {
-		return fmt.Errorf("could not get users to integrate: %w", err)
+		return nil, fmt.Errorf("could not get users to integrate: %w", err)
 	}
 
 	if len(users) == 0 {
 		log.Println("No users to integrate. Job finished.")
-		return nil
+		report.FinishedAt = time.Now()
+		return report, nil
 	}
 	log.Printf("Found %d users to process.", len(users))
 
-	// 2. Loop para processar cada produtor individualmente
-	for _, user := range users {
-		log.Printf("------------------ Processing Producer ID: %d ------------------", user.ProdutorID)
+	// Todas as entidades e produtores buscam até o mesmo instante, fixado no início do job,
+	// para que a janela seja a mesma para todos e a próxima execução continue exatamente dali.
+	until := report.StartedAt
+	queued := s.queuedIDs(ctx)
 
-		// 2.1. Autenticar na API Vestro com as credenciais do produtor atual
-		log.Printf("Authenticating user '%s' with Vestro API...", user.Login)
-		token, err := s.apiClient.Authenticate(ctx, user.Login, user.Senha)
-		if err != nil {
-			log.Printf("ERROR: Vestro authentication failed for user '%s': %v. Skipping.", user.Login, err)
-			continue // Pula para o próximo produtor
+	// 2. Processar os produtores em paralelo, com no máximo ProducerConcurrency ao mesmo tempo.
+	// Cada worker escreve apenas na sua posição do relatório, então não há disputa.
+	report.Producers = make([]dto.ProducerReport, len(users))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.opts.ProducerConcurrency, len(users)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				producerCtx, cancel := withTimeout(ctx, s.opts.ProducerTimeout, errProducerTimeout)
+				report.Producers[i] = s.processProducer(producerCtx, report.RunID, until, users[i], queued[users[i].ProdutorID])
+				cancel()
+			}
+		}()
+	}
+dispatch:
+	for i := range users {
+		select {
+		case jobs <- i:
+		case <-stop:
+			log.Printf("Stop requested, not starting the remaining %d producer(s).", len(users)-i)
+			report.Interrupted = "stop requested"
+			notStarted(report, users, i)
+			break dispatch
+		case <-ctx.Done():
+			log.Printf("Job interrupted (%v), not starting the remaining %d producer(s).", context.Cause(ctx), len(users)-i)
+			notStarted(report, users, i)
+			break dispatch
 		}
-		log.Println("Authentication successful for this user.")
+	}
+	close(jobs)
+	wg.Wait()
 
-		// 2.2. Buscar todos os dados para este produtor
-		lastSync := user.Data
-		// Garante que não buscamos um histórico muito longo na primeira vez
-		if time.Since(lastSync) > s.fetchSince {
-			lastSync = time.Now().Add(-s.fetchSince)
-		}
+	if ctx.Err() != nil {
+		report.Interrupted = context.Cause(ctx).Error()
+	}
+	report.FinishedAt = time.Now()
+	log.Printf("------------------ Job finished: %d producer(s) processed, %d failed ------------------", len(report.Producers), report.FailedCount())
+	return report, nil
+}
 
-		log.Printf("Fetching data since %v", lastSync)
-		userPayload, err := s.fetchAllDataForUser(ctx, token, user, lastSync)
+// processProducer autentica, busca e envia os dados de um produtor. queued são os IDs do
+// produtor na fila de reenvio, por entidade. Os logs saem com o prefixo do produtor para
+// continuarem legíveis com vários produtores em paralelo.
+func (s *ImporterService) processProducer(ctx context.Context, runID string, until time.Time, user dto.UserToIntegrate, queued map[string]map[int]time.Time) dto.ProducerReport {
+	logger := log.New(log.Writer(), fmt.Sprintf("[producer %d] ", user.ProdutorID), log.Flags()|log.Lmsgprefix)
+	started := time.Now()
+	result := dto.ProducerReport{ProdutorID: user.ProdutorID, Records: make(map[string]int)}
+	finish := func(status dto.ProducerStatus, err error) dto.ProducerReport {
+		result.Status = interruptedStatus(ctx, status)
+		result.DurationMs = time.Since(started).Milliseconds()
 		if err != nil {
-			log.Printf("ERROR: Failed to fetch data for producer %d: %v. Skipping.", user.ProdutorID, err)
-			continue
+			result.Error = err.Error()
 		}
+		return result
+	}
+	logger.Printf("------------------ Processing Producer ID: %d ------------------", user.ProdutorID)
 
-		// 2.3. Enviar dados se houver algo novo
-		if userPayload.IsEmpty() {
-			log.Printf("No new transactional data found for producer %d.", user.ProdutorID)
-			continue
+	// O filtro dos dados transacionais vem do cadastro do produtor (ou do padrão da configuração)
+	filter, err := user.TransactionFilter(s.opts.DefaultFilterMode)
+	if err != nil {
+		logger.Printf("ERROR: Invalid Vestro filter for producer %d: %v. Skipping.", user.ProdutorID, err)
+		return finish(dto.StatusFetchFailed, err)
+	}
+	entities, err := user.Entities(s.opts.Entities)
+	if err != nil {
+		logger.Printf("ERROR: Invalid entity selection for producer %d: %v. Skipping.", user.ProdutorID, err)
+		return finish(dto.StatusFetchFailed, err)
+	}
+	if entities != nil && len(entities) == 0 {
+		logger.Printf("No entity selected for producer %d, skipping.", user.ProdutorID)
+		return finish(dto.StatusNoData, nil)
+	}
+	logger.Printf("Entities selected: %s", entities)
+
+	// 2.1. Autenticar na API Vestro com as credenciais do produtor atual
+	logger.Printf("Authenticating user '%s' with Vestro API...", user.Login)
+	session, err := s.apiClient.Authenticate(ctx, user.Login, user.Senha)
+	if err != nil {
+		logger.Printf("ERROR: Vestro authentication failed for user '%s': %v. Skipping.", user.Login, err)
+		return finish(dto.StatusAuthFailed, err)
+	}
+	logger.Println("Authentication successful for this user.")
+
+	// 2.2. Buscar os dados selecionados para este produtor, retomando do último checkpoint entregue
+	supplies := s.resumeWindow(ctx, logger, user, dto.EntitySupplies, until)
+	sales := s.resumeWindow(ctx, logger, user, dto.EntityProductSales, until)
+	supplies.skipped = !entities.Has(dto.EntitySupplies)
+	sales.skipped = !entities.Has(dto.EntityProductSales)
+	supplies.queued = queued[dto.EntitySupplies]
+	sales.queued = queued[dto.EntityProductSales]
+	if n := len(supplies.queued) + len(sales.queued); n > 0 {
+		logger.Printf("Found %d record(s) waiting in the dead-letter queue; they will not be sent again.", n)
+	}
+
+	userPayload, hashes, err := s.fetchMasterData(ctx, logger, session, user, entities)
+	if err != nil {
+		logger.Printf("ERROR: Failed to fetch data for producer %d: %v. Skipping.", user.ProdutorID, err)
+		return finish(dto.StatusFetchFailed, err)
+	}
+	// As mudanças são detectadas sobre as listas completas; depois, as listas iguais às já
+	// entregues ficam fora do envio
+	snapshots := s.detectChanges(ctx, logger, userPayload)
+	changed := s.dropUnchanged(ctx, logger, user, userPayload, hashes)
+	// Os dados mestres vão sozinhos quando o acompanhamento de hashes achou uma lista que mudou
+	// ou quando nenhuma entidade transacional foi selecionada (e não há com quem mandá-los).
+	// Sem hashes, eles só acompanham os dados transacionais.
+	userPayload.MasterDataChanged = len(changed) > 0 || (supplies.skipped && sales.skipped)
+	for _, window := range []*entityWindow{supplies, sales} {
+		userPayload.EntityStatus[window.checkpoint.Entity] = window.status()
+	}
+	for name, status := range userPayload.EntityStatus {
+		if status.Status == dto.EntityOK && !isTransactional(name) {
+			result.Records[name] = userPayload.Count(name)
 		}
+	}
+	// Com a política partial, o produtor segue sem as listas que falharam
+	status := dto.StatusOK
+	var partialErr error
+	if failed := userPayload.FailedEntities(); len(failed) > 0 {
+		status = dto.StatusPartial
+		partialErr = fmt.Errorf("sent without %s", strings.Join(failed, ", "))
+		logger.Printf("Warning: continuing producer %d without %s.", user.ProdutorID, strings.Join(failed, ", "))
+	}
 
-		log.Printf("Sending payload for producer %d to Agriwin...", user.ProdutorID)
-		if err := s.notifier.Send(ctx, *userPayload); err != nil {
-			log.Printf("ERROR: Failed to send data for producer %d: %v. Skipping.", user.ProdutorID, err)
-			continue
+	// 2.3. Buscar os dados transacionais em streaming e enviá-los em lotes
+	logger.Printf("Fetching supplies %s and product sales %s (%s)", supplies.fetch, sales.fetch, filter)
+	d := newDelivery(runID, fmt.Sprintf("%s-%d", runID, user.ProdutorID), supplies, sales, until)
+	d.masterHashes = changed
+	d.snapshots = snapshots
+	result.Batches, err = s.forwardTransactional(ctx, logger, d, session, filter, supplies, sales, userPayload, &result)
+	if err != nil {
+		logger.Printf("ERROR: Failed to import data for producer %d after %d batch(es): %v. Skipping.", user.ProdutorID, result.Batches, err)
+		if errors.Is(err, errSendFailed) {
+			result.DeadLettered = errors.Is(err, errDeadLettered)
+			return finish(dto.StatusSendFailed, err)
 		}
-		log.Printf("Successfully processed producer %d.", user.ProdutorID)
+		return finish(dto.StatusFetchFailed, err)
 	}
 
-	log.Println("------------------ Job finished successfully ------------------")
-	return nil
-}
+	// 2.4. Confirmar ao Agriwin a janela importada (inclusive quando ela veio vazia). Com um
+	// registro inválido, a confirmação para antes dele
+	result.Acked = s.acknowledge(ctx, logger, dto.SyncAcknowledgement{
+		RunID:        runID,
+		ProdutorID:   user.ProdutorID,
+		WindowStart:  earliest(supplies.fetch.Since, sales.fetch.Since),
+		WindowEnd:    earliest(supplies.confirmedUntil(until), sales.confirmedUntil(until)),
+		Supplies:     supplies.ack(),
+		ProductSales: sales.ack(),
+	})
 
-// fetchAllDataForUser busca todos os dados (mestres e transacionais) para um usuário.
-func (s *ImporterService) fetchAllDataForUser(ctx context.Context, token string, user dto.UserToIntegrate, since time.Time) (*dto.IntegrationPayload, error) {
-	var wg sync.WaitGroup
-	errChan := make(chan error, 7) // 2 transacionais + 5 de cadastro
+	// Registros inválidos ficaram de fora: o produtor não está completo
+	if n := len(result.InvalidRecords); n > 0 {
+		status = dto.StatusPartial
+		partialErr = errors.Join(partialErr, fmt.Errorf("skipped %d invalid record(s)", n))
+		logger.Printf("Warning: skipped %d invalid record(s) for producer %d; the checkpoint stays before the first one.", n, user.ProdutorID)
+	}
+
+	if result.Batches == 0 && status == dto.StatusOK {
+		logger.Printf("No new data found for producer %d.", user.ProdutorID)
+		return finish(dto.StatusNoData, nil)
+	}
+	logger.Printf("Successfully processed producer %d (%d batch(es) sent).", user.ProdutorID, result.Batches)
+	return finish(status, partialErr)
+}
 
+// fetchMasterData busca os dados mestres de um usuário. Eles formam a base do primeiro
+// payload enviado; os transacionais vêm depois, em streaming, via forwardTransactional.
+// As buscas rodam em paralelo sob um contexto compartilhado. Com FetchFailFast, a primeira
+// falha cancela as demais e é devolvida; com FetchPartial, o payload segue com as listas que
+// deram certo e o status de cada uma em EntityStatus.
+// Entidades fora de entities não são buscadas e ficam com status skipped. Com masterHashes,
+// também devolve o hash do conteúdo de cada lista buscada.
+func (s *ImporterService) fetchMasterData(ctx context.Context, logger *log.Logger, session portas.VestroSession, user dto.UserToIntegrate, entities dto.EntitySet) (*dto.IntegrationPayload, map[string]string, error) {
 	payload := &dto.IntegrationPayload{
-		ProdutorID: user.ProdutorID,
-		FetchedAt:  time.Now(),
-	}
-
-	// O identificador na Vestro para filtrar os dados transacionais será o login.
-	// A propriedade de filtro será "driver", que é um palpite comum.
-	// Se o login for de um frentista, a propriedade pode ser "employee".
-	vestroIdentifier := user.Login
-
-	// --- Buscas Transacionais (com filtro de data e usuário) ---
-	wg.Add(2)
-	go s.fetchData(ctx, &wg, errChan, "supplies", func() (interface{}, error) {
-		return s.apiClient.GetSupplies(ctx, token, since, vestroIdentifier)
-	}, &payload.Supplies)
-	go s.fetchData(ctx, &wg, errChan, "productSales", func() (interface{}, error) {
-		return s.apiClient.GetProductSales(ctx, token, since, vestroIdentifier)
-	}, &payload.ProductSales)
-
-	// --- Buscas de Dados Mestres (sem filtro de data ou usuário específico, mas sob o token do usuário) ---
-	wg.Add(5)
-	go s.fetchData(ctx, &wg, errChan, "products", func() (interface{}, error) { return s.apiClient.GetProducts(ctx, token) }, &payload.Products)
-	go s.fetchData(ctx, &wg, errChan, "fuelTypes", func() (interface{}, error) { return s.apiClient.GetFuelTypes(ctx, token) }, &payload.FuelTypes)
-	go s.fetchData(ctx, &wg, errChan, "vehicles", func() (interface{}, error) { return s.apiClient.GetVehicles(ctx, token) }, &payload.Vehicles)
-	go s.fetchData(ctx, &wg, errChan, "drivers", func() (interface{}, error) { return s.apiClient.GetDrivers(ctx, token) }, &payload.Drivers)
-	go s.fetchData(ctx, &wg, errChan, "employees", func() (interface{}, error) { return s.apiClient.GetEmployees(ctx, token) }, &payload.Employees)
+		ProdutorID:   user.ProdutorID,
+		FetchedAt:    time.Now(),
+		EntityStatus: make(map[string]dto.EntityStatus),
+	}
 
-	wg.Wait()
-	close(errChan)
+	fetchCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	f := &masterFetch{
+		ctx:      fetchCtx,
+		cancel:   cancel,
+		logger:   logger,
+		failFast: s.opts.FetchPolicy == dto.FetchFailFast,
+		entities: entities,
+		status:   payload.EntityStatus,
+	}
+	if s.masterHashes != nil {
+		f.hashes = make(map[string]string)
+	}
+
+	// --- Buscas de Dados Mestres (sem filtro de data ou usuário específico, mas sob a sessão do usuário) ---
+	fetchEntity(f, dto.EntityProducts, session.GetProducts, &payload.Products)
+	fetchEntity(f, dto.EntityFuelTypes, session.GetFuelTypes, &payload.FuelTypes)
+	fetchEntity(f, dto.EntityVehicles, session.GetVehicles, &payload.Vehicles)
+	fetchEntity(f, dto.EntityDrivers, session.GetDrivers, &payload.Drivers)
+	fetchEntity(f, dto.EntityEmployees, session.GetEmployees, &payload.Employees)
+	f.wg.Wait()
+
+	if f.failFast && f.firstErr != nil {
+		return nil, nil, f.firstErr
+	}
+	return payload, f.hashes, nil
+}
+
+// forwardTransactional percorre abastecimentos e vendas em streaming e envia um lote
+// ao Agriwin a cada BatchSize registros, mantendo o uso de memória constante.
+// Os dados mestres vão apenas no primeiro envio. Registros já entregues (pelos IDs do checkpoint)
+// são descartados e o checkpoint de cada entidade só avança depois que o lote é aceito.
+// Conta os registros buscados em records e retorna quantos envios foram feitos.
+func (s *ImporterService) forwardTransactional(ctx context.Context, logger *log.Logger, d *delivery, session portas.VestroSession, filter dto.TransactionFilter, supplies, sales *entityWindow, payload *dto.IntegrationPayload, result *dto.ProducerReport) (int, error) {
+	flush := func(last bool) error {
+		// O envio final vai mesmo vazio quando já houve envios, para marcar o fim do conjunto
+		if payload.IsEmpty() && !(last && d.sequence > 0) {
+			return nil
+		}
+		// O checkpoint só avança com a entrega. Um lote guardado na fila de reenvio leva os
+		// checkpoints que gravaria, e o replay os grava quando entregá-lo.
+		d.checkpoints = nil
+		for _, window := range []*entityWindow{supplies, sales} {
+			if window.persist && len(window.batch) > 0 {
+				d.checkpoints = append(d.checkpoints, s.committed(window))
+			}
+		}
+		if err := s.sendBatch(ctx, logger, d, *payload, last); err != nil {
+			return err
+		}
+		s.commit(ctx, logger, supplies)
+		s.commit(ctx, logger, sales)
+		*payload = dto.IntegrationPayload{ProdutorID: payload.ProdutorID, FetchedAt: payload.FetchedAt}
+		return nil
+	}
+	// O lote cheio só é enviado quando chega o próximo registro; assim, ao fim do stream,
+	// o lote pendente é sabidamente o último e pode ser marcado como tal.
+	flushIfFull := func() error {
+		if len(payload.Supplies)+len(payload.ProductSales) < s.opts.BatchSize {
+			return nil
+		}
+		return flush(false)
+	}
 
-	for fetchErr := range errChan {
-		if fetchErr != nil {
-			return nil, fetchErr
+	if supplies.skipped {
+		logger.Println("Supplies not selected for this run, skipping.")
+	} else {
+		logger.Println("Streaming supplies...")
+		skipped := 0
+		for supply, err := range session.StreamSupplies(ctx, supplies.fetch, filter) {
+			var invalid *dto.RecordError
+			if errors.As(err, &invalid) {
+				supplies.hold()
+				result.InvalidRecords = append(result.InvalidRecords, dto.InvalidRecord{Entity: supplies.checkpoint.Entity, ID: invalid.ID, Error: invalid.Err.Error()})
+				continue
+			}
+			if err != nil {
+				return d.sequence, fmt.Errorf("failed to fetch supplies: %w", err)
+			}
+			if supplies.seen(supply.ID) {
+				skipped++
+				continue
+			}
+			if err := flushIfFull(); err != nil {
+				return d.sequence, err
+			}
+			payload.Supplies = append(payload.Supplies, supply)
+			supplies.add(supply.Date.Time, supply.ID)
+			result.Records[dto.EntitySupplies]++
+		}
+		if skipped > 0 {
+			logger.Printf("Skipped %d supplies already delivered.", skipped)
 		}
 	}
-	return payload, nil
+
+	if sales.skipped {
+		logger.Println("Product sales not selected for this run, skipping.")
+	} else {
+		logger.Println("Streaming productSales...")
+		skipped := 0
+		for sale, err := range session.StreamProductSales(ctx, sales.fetch, filter) {
+			var invalid *dto.RecordError
+			if errors.As(err, &invalid) {
+				sales.hold()
+				result.InvalidRecords = append(result.InvalidRecords, dto.InvalidRecord{Entity: sales.checkpoint.Entity, ID: invalid.ID, Error: invalid.Err.Error()})
+				continue
+			}
+			if err != nil {
+				return d.sequence, fmt.Errorf("failed to fetch productSales: %w", err)
+			}
+			if sales.seen(sale.ID) {
+				skipped++
+				continue
+			}
+			if err := flushIfFull(); err != nil {
+				return d.sequence, err
+			}
+			payload.ProductSales = append(payload.ProductSales, sale)
+			sales.add(sale.Date.Time, sale.ID)
+			result.Records[dto.EntityProductSales]++
+		}
+		if skipped > 0 {
+			logger.Printf("Skipped %d product sales already delivered.", skipped)
+		}
+	}
+
+	err := flush(true)
+	return d.sequence, err
 }
 
-// (A função helper 'fetchData' continua a mesma)
-func (s *ImporterService) fetchData(ctx context.Context, wg *sync.WaitGroup, errChan chan<- error, name string, fetchFunc func() (interface{}, error), result interface{}) {
-	defer wg.Done()
-	log.Printf("Fetching %s...", name)
-	data, err := fetchFunc()
-	if err != nil {
-		errChan <- fmt.Errorf("failed to fetch %s: %w", name, err)
+// masterFetch acompanha as buscas paralelas de dados mestres de um produtor.
+type masterFetch struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	logger   *log.Logger
+	failFast bool
+	entities dto.EntitySet
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	status   map[string]dto.EntityStatus
+	hashes   map[string]string // nil quando os hashes não são acompanhados
+	firstErr error
+}
+
+// fetchEntity busca uma lista em uma goroutine e a grava em result, registrando o status da entidade.
+// Uma lista buscada sem registros vira [] no payload; uma deixada de fora continua null.
+func fetchEntity[T any](f *masterFetch, name string, fetch func(context.Context) ([]T, error), result *[]T) {
+	if !f.entities.Has(name) {
+		// As buscas anteriores já podem estar gravando o status delas
+		f.mu.Lock()
+		f.status[name] = dto.EntityStatus{Status: dto.EntitySkipped}
+		f.mu.Unlock()
 		return
 	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.logger.Printf("Fetching %s...", name)
+		data, err := fetch(f.ctx)
+		var hash string
+		if err == nil && f.hashes != nil {
+			if hash, err = dto.ContentHash(data); err != nil {
+				// Sem hash, a lista vai completa
+				f.logger.Printf("Warning: could not hash %s: %v", name, err)
+				hash, err = "", nil
+			}
+		}
 
-	switch r := result.(type) {
-	case *[]dto.Supply:
-		*r = data.([]dto.Supply)
-	case *[]dto.ProductSale:
-		*r = data.([]dto.ProductSale)
-	case *[]dto.Product:
-		*r = data.([]dto.Product)
-	case *[]dto.FuelType:
-		*r = data.([]dto.FuelType)
-	case *[]dto.Vehicle:
-		*r = data.([]dto.Vehicle)
-	case *[]dto.Driver:
-		*r = data.([]dto.Driver)
-	case *[]dto.Employee:
-		*r = data.([]dto.Employee)
-	}
-
-	log.Printf("Successfully fetched %s.", name)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if err == nil {
+			if data == nil {
+				data = []T{}
+			}
+			*result = data
+			f.status[name] = dto.EntityStatus{Status: dto.EntityOK}
+			if hash != "" {
+				f.hashes[name] = hash
+			}
+			f.logger.Printf("Successfully fetched %s.", name)
+			return
+		}
+
+		// Interrompida por outra busca que falhou antes ou pelo cancelamento do job. No segundo
+		// caso, o cancelamento também interrompe a busca com FetchFailFast
+		if f.ctx.Err() != nil {
+			f.status[name] = dto.EntityStatus{Status: dto.EntityCancelled, Error: context.Cause(f.ctx).Error()}
+			if f.firstErr == nil {
+				f.firstErr = fmt.Errorf("fetching %s was cancelled: %w", name, context.Cause(f.ctx))
+			}
+			return
+		}
+		err = fmt.Errorf("failed to fetch %s: %w", name, err)
+		f.logger.Printf("ERROR: %v", err)
+		f.status[name] = dto.EntityStatus{Status: dto.EntityFailed, Error: err.Error()}
+		if f.firstErr == nil {
+			f.firstErr = err
+			if f.failFast {
+				f.cancel(err)
+			}
+		}
+	}()
 }
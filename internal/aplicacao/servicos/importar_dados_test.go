@@ -0,0 +1,45 @@
+package servicos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"vestro/internal/dto"
+)
+
+// blockingVestro devolve os dados mestres só quando o contexto da busca acaba.
+type blockingVestro struct {
+	*fakeVestro
+}
+
+func (v blockingVestro) GetProducts(ctx context.Context) ([]dto.Product, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestFetchMasterDataCancelledByTheJob(t *testing.T) {
+	errStopped := errors.New("job stopped")
+	for _, policy := range []dto.FetchPolicy{dto.FetchPartial, dto.FetchFailFast} {
+		t.Run(string(policy), func(t *testing.T) {
+			importer := newTestImporter(&fakeVestro{}, &fakeAgriwin{}, nil, nil, Options{FetchPolicy: policy})
+			ctx, cancel := context.WithCancelCause(context.Background())
+			cancel(errStopped)
+
+			payload, _, err := importer.fetchMasterData(ctx, log.New(io.Discard, "", 0), blockingVestro{&fakeVestro{}}, testUser(), nil)
+			if policy == dto.FetchFailFast {
+				if !errors.Is(err, errStopped) {
+					t.Errorf("error = %v, want the job cancellation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v, want the partial payload", err)
+			}
+			if status := payload.EntityStatus[dto.EntityProducts]; status.Status != dto.EntityCancelled || status.Error != errStopped.Error() {
+				t.Errorf("products status = %+v, want cancelled by the job", status)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package servicos
+
+import (
+	"context"
+	"errors"
+	"time"
+	"vestro/internal/dto"
+)
+
+var (
+	errJobTimeout      = errors.New("job deadline exceeded")
+	errProducerTimeout = errors.New("producer deadline exceeded")
+)
+
+// withTimeout limita ctx a timeout, registrando cause como motivo. timeout <= 0 = sem prazo.
+func withTimeout(ctx context.Context, timeout time.Duration, cause error) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, timeout, cause)
+}
+
+// interruptedStatus troca o status de falha de um produtor quando ela aconteceu porque o
+// contexto acabou: pelo prazo do produtor (timed_out) ou pelo fim do job (interrupted).
+func interruptedStatus(ctx context.Context, status dto.ProducerStatus) dto.ProducerStatus {
+	if !status.Failed() || ctx.Err() == nil {
+		return status
+	}
+	if errors.Is(context.Cause(ctx), errProducerTimeout) {
+		return dto.StatusTimedOut
+	}
+	return dto.StatusInterrupted
+}
+
+// notStarted marca no relatório os produtores que o job não chegou a processar.
+func notStarted(report *dto.JobReport, users []dto.UserToIntegrate, from int) {
+	for i := from; i < len(users); i++ {
+		report.Producers[i] = dto.ProducerReport{ProdutorID: users[i].ProdutorID, Status: dto.StatusNotStarted}
+	}
+}
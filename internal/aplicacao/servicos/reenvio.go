@@ -0,0 +1,141 @@
+package servicos
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"vestro/internal/aplicacao/portas"
+	"vestro/internal/dto"
+)
+
+// ReplayOptions configura o reenvio da fila de envios que falharam.
+type ReplayOptions struct {
+	// MaxAttempts é quantas vezes cada entrada é tentada em uma execução do replay.
+	MaxAttempts int
+	// BaseDelay e MaxDelay definem o backoff exponencial entre as tentativas.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// FetchOverlap é a mesma sobreposição da importação, usada para podar os IDs vistos
+	// dos checkpoints gravados pelo replay.
+	FetchOverlap time.Duration
+}
+
+// ReplayService reenvia ao Agriwin os payloads guardados na fila de reenvio.
+type ReplayService struct {
+	notifier    portas.Notifier
+	deadLetters portas.DeadLetterQueue
+	checkpoints portas.CheckpointStore
+	opts        ReplayOptions
+}
+
+// NewReplayService cria o serviço de reenvio. checkpoints é opcional: com nil, os checkpoints
+// guardados com os lotes não são gravados.
+func NewReplayService(notifier portas.Notifier, deadLetters portas.DeadLetterQueue, checkpoints portas.CheckpointStore, opts ReplayOptions) *ReplayService {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	return &ReplayService{notifier: notifier, deadLetters: deadLetters, checkpoints: checkpoints, opts: opts}
+}
+
+// Replay reenvia as entradas da mais antiga para a mais nova e apaga cada uma assim que o
+// Agriwin a aceita. Quando uma entrada esgota as tentativas, as seguintes do mesmo produtor
+// ficam para a próxima execução, para que os envios dele não cheguem fora de ordem.
+func (s *ReplayService) Replay(ctx context.Context) (*dto.ReplayReport, error) {
+	report := &dto.ReplayReport{StartedAt: time.Now()}
+	entries, err := s.deadLetters.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list dead letters: %w", err)
+	}
+	report.Total = len(entries)
+	log.Printf("Found %d dead letter(s) to replay.", len(entries))
+
+	blocked := make(map[int]bool)
+	for _, entry := range entries {
+		if blocked[entry.ProdutorID] {
+			report.Remaining++
+			continue
+		}
+		if err := s.replay(ctx, &entry); err != nil {
+			if ctx.Err() != nil {
+				report.Remaining = report.Total - report.Replayed
+				report.FinishedAt = time.Now()
+				return report, ctx.Err()
+			}
+			log.Printf("ERROR: Giving up on dead letter %s for now: %v", entry.ID, err)
+			blocked[entry.ProdutorID] = true
+			report.Remaining++
+			continue
+		}
+		report.Replayed++
+	}
+
+	report.FinishedAt = time.Now()
+	log.Printf("------------------ Replay finished: %d replayed, %d remaining ------------------", report.Replayed, report.Remaining)
+	return report, nil
+}
+
+// replay tenta reenviar uma entrada com backoff, registrando cada falha nela. Entregue a
+// entrada, grava os checkpoints que vieram com ela antes de apagá-la.
+func (s *ReplayService) replay(ctx context.Context, entry *dto.DeadLetter) error {
+	for attempt := 1; ; attempt++ {
+		log.Printf("Replaying dead letter %s for producer %d (attempt %d, %d before)...", entry.ID, entry.ProdutorID, attempt, entry.Attempts)
+		err := s.notifier.Send(ctx, entry.Payload)
+		if err == nil {
+			for _, cp := range entry.Checkpoints {
+				s.advance(ctx, cp)
+			}
+			if err := s.deadLetters.Delete(ctx, entry.ID); err != nil {
+				log.Printf("Warning: dead letter %s was delivered but could not be deleted: %v", entry.ID, err)
+			}
+			return nil
+		}
+
+		entry.Attempts++
+		entry.Error = err.Error()
+		entry.LastAttemptAt = time.Now()
+		if putErr := s.deadLetters.Put(ctx, *entry); putErr != nil {
+			log.Printf("Warning: failed to update dead letter %s: %v", entry.ID, putErr)
+		}
+		if attempt >= s.opts.MaxAttempts {
+			return err
+		}
+
+		delay := s.opts.BaseDelay << (attempt - 1)
+		if delay <= 0 || delay > s.opts.MaxDelay {
+			delay = s.opts.MaxDelay
+		}
+		log.Printf("Failed to replay dead letter %s, retrying in %v: %v", entry.ID, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// advance junta o checkpoint de um lote reenviado ao checkpoint gravado. A importação pode
+// ter avançado desde a falha, então a marca d'água nunca volta.
+// Uma falha só é logada: o lote já foi entregue e os registros dele ficam fora da janela
+// apenas até a sobreposição.
+func (s *ReplayService) advance(ctx context.Context, cp dto.Checkpoint) {
+	if s.checkpoints == nil {
+		return
+	}
+	saved, ok, err := s.checkpoints.Load(ctx, cp.ProdutorID, cp.Entity)
+	if err != nil {
+		log.Printf("Warning: could not load the %s checkpoint of producer %d: %v", cp.Entity, cp.ProdutorID, err)
+		return
+	}
+	if ok {
+		saved.Merge(cp)
+		cp = saved
+	}
+	cp.Prune(cp.LastTimestamp.Add(-s.opts.FetchOverlap))
+	cp.UpdatedAt = time.Now()
+	if err := s.checkpoints.Save(ctx, cp); err != nil {
+		log.Printf("Warning: failed to save the %s checkpoint of producer %d: %v", cp.Entity, cp.ProdutorID, err)
+	}
+}
@@ -0,0 +1,85 @@
+package servicos
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"vestro/internal/dto"
+)
+
+func TestQueuedRecordsAreNotSentAgain(t *testing.T) {
+	first, second, later := ago(90*time.Minute), ago(80*time.Minute), ago(70*time.Minute)
+	vestro := &fakeVestro{}
+	vestro.setSupplies(vestroRecord{id: 1, date: first}, vestroRecord{id: 2, date: second})
+	other := testUser()
+	other.ProdutorID = 8
+	agriwin := &fakeAgriwin{failing: true, users: []dto.UserToIntegrate{testUser(), other}}
+	checkpoints := newMemoryCheckpoints()
+	deadLetters := newMemoryDeadLetters()
+	importer := newTestImporter(vestro, agriwin, checkpoints, deadLetters, Options{})
+
+	if _, err := importer.RunImport(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := checkpoints.Load(context.Background(), 7, dto.EntitySupplies); ok {
+		t.Errorf("checkpoint saved for a dead-lettered batch")
+	}
+	queued, _ := deadLetters.List(context.Background())
+	if len(queued) != 2 {
+		t.Fatalf("dead-letter queue has %d entries, want one per producer", len(queued))
+	}
+
+	// Na execução seguinte só o registro novo é enviado, e a fila é lida uma única vez
+	agriwin.failing = false
+	vestro.setSupplies(vestroRecord{id: 1, date: first}, vestroRecord{id: 2, date: second}, vestroRecord{id: 3, date: later})
+	deadLetters.lists = 0
+	if _, err := importer.RunImport(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := agriwin.sentSupplies(); !slices.Equal(got, []int{3, 3}) {
+		t.Errorf("delivered supplies = %v, want only the new record for each producer", got)
+	}
+	if deadLetters.lists != 1 {
+		t.Errorf("dead-letter queue listed %d times in the job, want 1", deadLetters.lists)
+	}
+}
+
+func TestReplayMergesTheCheckpoint(t *testing.T) {
+	first, second, later := ago(90*time.Minute), ago(80*time.Minute), ago(70*time.Minute)
+	vestro := &fakeVestro{}
+	vestro.setSupplies(vestroRecord{id: 1, date: first})
+	agriwin := &fakeAgriwin{}
+	checkpoints := newMemoryCheckpoints()
+	deadLetters := newMemoryDeadLetters()
+	opts := Options{FetchOverlap: 30 * time.Minute}
+	importer := newTestImporter(vestro, agriwin, checkpoints, deadLetters, opts)
+
+	// O primeiro lote é aceito e o segundo vai para a fila
+	runOnce(t, importer)
+	agriwin.failing = true
+	vestro.setSupplies(vestroRecord{id: 1, date: first}, vestroRecord{id: 2, date: second})
+	runOnce(t, importer)
+
+	agriwin.failing = false
+	replay := NewReplayService(agriwin, deadLetters, checkpoints, ReplayOptions{MaxAttempts: 1, FetchOverlap: opts.FetchOverlap})
+	if _, err := replay.Replay(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cp, _, _ := checkpoints.Load(context.Background(), 7, dto.EntitySupplies)
+	if !cp.LastTimestamp.Equal(second) {
+		t.Errorf("checkpoint = %s, want the replayed record %s", cp.LastTimestamp, second)
+	}
+	for _, id := range []int{1, 2} {
+		if _, ok := cp.SeenIDs[id]; !ok {
+			t.Errorf("seen ids = %v, want %d", cp.SeenIDs, id)
+		}
+	}
+
+	// Com o checkpoint do replay, a importação seguinte só envia o registro novo
+	vestro.setSupplies(vestroRecord{id: 1, date: first}, vestroRecord{id: 2, date: second}, vestroRecord{id: 3, date: later})
+	runOnce(t, importer)
+	if got := agriwin.sentSupplies(); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("delivered supplies = %v, want [1 2 3]", got)
+	}
+}
@@ -1,20 +1,101 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+	"vestro/internal/dto"
 
 	"github.com/joho/godotenv"
 )
 
 // Config armazena todas as configurações da aplicação.
 type Config struct {
-	VestroBaseURL   string
-	GrailsAppURL    string
-	AgriwinUsersURL string
-	FetchDataSince  time.Duration
+	VestroBaseURL    string
+	GrailsAppURL     string
+	AgriwinUsersURL  string
+	AgriwinAckURL    string
+	FetchDataSince   time.Duration
+	FetchOverlap     time.Duration
+	ForwardBatchSize int
+	ForwardMaxBytes  int
+
+	// Paralelismo: produtores processados ao mesmo tempo e requisições simultâneas à Vestro
+	ProducerConcurrency int
+	VestroMaxInFlight   int
+
+	// Prazos do job inteiro e de cada produtor (0 = sem prazo)
+	JobTimeout      time.Duration
+	ProducerTimeout time.Duration
+
+	// Filtro Vestro padrão dos dados transacionais (driver, employee, company ou none)
+	DefaultFilterMode dto.FilterMode
+
+	// O que fazer quando uma lista de dados mestres falha (fail_fast ou partial)
+	FetchPolicy dto.FetchPolicy
+
+	// Entidades buscadas em cada execução (nil = todas)
+	Entities dto.EntitySet
+
+	// Retry das chamadas à API Vestro
+	VestroRetryMaxAttempts int
+	VestroRetryBaseDelay   time.Duration
+	VestroRetryMaxDelay    time.Duration
+	VestroRetryBudget      int
+
+	// Páginas de um mesmo endpoint buscadas em paralelo
+	VestroPageConcurrency int
+
+	// Validade e renovação do token Vestro
+	VestroTokenTTL      time.Duration
+	VestroRefreshMargin time.Duration
+	VestroRefreshPath   string
+
+	// Parâmetro da Vestro para o fim da janela dos dados transacionais. O padrão é endDate;
+	// definido vazio, o fim não é enviado e a janela só é filtrada no cliente
+	VestroEndDateParam string
+
+	// Autenticação das chamadas ao Agriwin (none, bearer, oauth2 ou hmac). Os segredos
+	// também podem vir de arquivos, pelas variáveis terminadas em _FILE
+	AgriwinAuthMode          string
+	AgriwinAuthToken         string
+	AgriwinOAuthTokenURL     string
+	AgriwinOAuthClientID     string
+	AgriwinOAuthClientSecret string
+	AgriwinOAuthScope        string
+	AgriwinHMACSecret        string
+	AgriwinHMACKeyID         string
+
+	// Checkpoints de sincronização por produtor (file, bolt, sqlite ou none); sqlite exige um
+	// binário compilado com CGO_ENABLED=1
+	CheckpointStore string
+	CheckpointPath  string
+
+	// Fila de envios que falharam (diretório vazio desativa) e o reenvio pelo comando replay
+	DeadLetterDir     string
+	ReplayMaxAttempts int
+	ReplayBaseDelay   time.Duration
+	ReplayMaxDelay    time.Duration
+
+	// Snapshots dos dados mestres usados para detectar mudanças (diretório vazio desativa)
+	SnapshotDir string
+
+	// Modo serve: expressão cron (vazio = usa o intervalo) e atraso aleatório de cada execução
+	ServeSchedule string
+	ServeInterval time.Duration
+	ServeJitter   time.Duration
+
+	// Dry-run: grava os payloads em DryRunOutput (diretório ou "-" para a saída padrão)
+	// no formato DryRunFormat (json ou ndjson) em vez de enviá-los ao Grails
+	DryRun       bool
+	DryRunOutput string
+	DryRunFormat string
+
+	// Fuso das datas no formato da Vestro (ex.: UTC, America/Sao_Paulo)
+	VestroLocation *time.Location
 }
 
 // Load carrega as configurações das variáveis de ambiente.
@@ -27,11 +108,103 @@ func Load() (*Config, error) {
 		fetchHours = 24
 	}
 
+	filterMode, err := dto.ParseFilterMode(getEnv("VESTRO_DEFAULT_FILTER_MODE", string(dto.FilterByDriver)))
+	if err != nil || filterMode == "" {
+		log.Printf("Invalid VESTRO_DEFAULT_FILTER_MODE, using driver. Error: %v", err)
+		filterMode = dto.FilterByDriver
+	}
+
+	fetchPolicy, err := dto.ParseFetchPolicy(getEnv("FETCH_POLICY", string(dto.FetchFailFast)))
+	if err != nil {
+		log.Printf("Invalid FETCH_POLICY, using fail_fast. Error: %v", err)
+		fetchPolicy = dto.FetchFailFast
+	}
+
+	entities, err := dto.ParseEntitySet(getEnv("ENTITIES", ""))
+	if err != nil {
+		log.Printf("Invalid ENTITIES, fetching all entities. Error: %v", err)
+		entities = nil
+	}
+
+	// Segredos do Agriwin: um arquivo ilegível impede a inicialização, em vez de seguir sem auth
+	var authToken, oauthClientSecret, hmacSecret string
+	for key, target := range map[string]*string{
+		"AGRIWIN_AUTH_TOKEN":          &authToken,
+		"AGRIWIN_OAUTH_CLIENT_SECRET": &oauthClientSecret,
+		"AGRIWIN_HMAC_SECRET":         &hmacSecret,
+	} {
+		if *target, err = getEnvSecret(key); err != nil {
+			return nil, err
+		}
+	}
+
+	vestroLocation, err := time.LoadLocation(getEnv("VESTRO_TIMEZONE", "UTC"))
+	if err != nil {
+		log.Printf("Invalid VESTRO_TIMEZONE, using UTC. Error: %v", err)
+		vestroLocation = time.UTC
+	}
+
 	return &Config{
-		VestroBaseURL:   getEnv("VESTRO_API_URL", ""),
-		GrailsAppURL:    getEnv("GRAILS_APP_URL", ""),
-		AgriwinUsersURL: getEnv("AGRIWIN_USERS_URL", ""),
-		FetchDataSince:  time.Duration(fetchHours) * time.Hour,
+		VestroBaseURL:    getEnv("VESTRO_API_URL", ""),
+		GrailsAppURL:     getEnv("GRAILS_APP_URL", ""),
+		AgriwinUsersURL:  getEnv("AGRIWIN_USERS_URL", ""),
+		AgriwinAckURL:    getEnv("AGRIWIN_ACK_URL", ""),
+		FetchDataSince:   time.Duration(fetchHours) * time.Hour,
+		FetchOverlap:     getEnvDuration("FETCH_OVERLAP", 30*time.Minute),
+		ForwardBatchSize: getEnvInt("FORWARD_BATCH_SIZE", 1000),
+		ForwardMaxBytes:  getEnvInt("FORWARD_MAX_BYTES", 1<<20),
+
+		ProducerConcurrency: getEnvInt("PRODUCER_CONCURRENCY", 4),
+		VestroMaxInFlight:   getEnvInt("VESTRO_MAX_IN_FLIGHT", 16),
+
+		JobTimeout:      getEnvDuration("JOB_TIMEOUT", 0),
+		ProducerTimeout: getEnvDuration("PRODUCER_TIMEOUT", 0),
+
+		DefaultFilterMode: filterMode,
+		FetchPolicy:       fetchPolicy,
+		Entities:          entities,
+
+		VestroRetryMaxAttempts: getEnvInt("VESTRO_RETRY_MAX_ATTEMPTS", 5),
+		VestroRetryBaseDelay:   getEnvDuration("VESTRO_RETRY_BASE_DELAY", 500*time.Millisecond),
+		VestroRetryMaxDelay:    getEnvDuration("VESTRO_RETRY_MAX_DELAY", 30*time.Second),
+		VestroRetryBudget:      getEnvInt("VESTRO_RETRY_BUDGET", 200),
+
+		VestroPageConcurrency: getEnvInt("VESTRO_PAGE_CONCURRENCY", 4),
+
+		VestroTokenTTL:      getEnvDuration("VESTRO_TOKEN_TTL", 0),
+		VestroRefreshMargin: getEnvDuration("VESTRO_TOKEN_REFRESH_MARGIN", 2*time.Minute),
+		VestroRefreshPath:   getEnv("VESTRO_SESSION_REFRESH_PATH", ""),
+
+		VestroEndDateParam: getEnv("VESTRO_END_DATE_PARAM", "endDate"),
+
+		AgriwinAuthMode:          getEnv("AGRIWIN_AUTH_MODE", "none"),
+		AgriwinAuthToken:         authToken,
+		AgriwinOAuthTokenURL:     getEnv("AGRIWIN_OAUTH_TOKEN_URL", ""),
+		AgriwinOAuthClientID:     getEnv("AGRIWIN_OAUTH_CLIENT_ID", ""),
+		AgriwinOAuthClientSecret: oauthClientSecret,
+		AgriwinOAuthScope:        getEnv("AGRIWIN_OAUTH_SCOPE", ""),
+		AgriwinHMACSecret:        hmacSecret,
+		AgriwinHMACKeyID:         getEnv("AGRIWIN_HMAC_KEY_ID", ""),
+
+		CheckpointStore: getEnv("CHECKPOINT_STORE", "file"),
+		CheckpointPath:  getEnv("CHECKPOINT_PATH", "data/checkpoints.json"),
+
+		DeadLetterDir:     getEnv("DEAD_LETTER_DIR", "data/dead-letter"),
+		ReplayMaxAttempts: getEnvInt("REPLAY_MAX_ATTEMPTS", 5),
+		ReplayBaseDelay:   getEnvDuration("REPLAY_BASE_DELAY", 2*time.Second),
+		ReplayMaxDelay:    getEnvDuration("REPLAY_MAX_DELAY", time.Minute),
+
+		SnapshotDir: getEnv("SNAPSHOT_DIR", "data/snapshots"),
+
+		ServeSchedule: getEnv("SERVE_SCHEDULE", ""),
+		ServeInterval: getEnvDuration("SERVE_INTERVAL", time.Hour),
+		ServeJitter:   getEnvDuration("SERVE_JITTER", 0),
+
+		DryRun:       getEnvBool("DRY_RUN", false),
+		DryRunOutput: getEnv("DRY_RUN_OUTPUT", "data/dry-run"),
+		DryRunFormat: getEnv("DRY_RUN_FORMAT", "json"),
+
+		VestroLocation: vestroLocation,
 	}, nil
 }
 
@@ -42,3 +215,47 @@ func getEnv(key, fallback string) string {
 	log.Printf("Environment variable %s not set, using fallback: '%s'", key, fallback)
 	return fallback
 }
+
+// getEnvSecret lê um segredo da variável key ou, se key_FILE estiver definida, do arquivo
+// indicado por ela (como os secrets do Docker e do Kubernetes). O valor nunca é logado.
+func getEnvSecret(key string) (string, error) {
+	if path, ok := os.LookupEnv(key + "_FILE"); ok && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s_FILE: %w", key, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return os.Getenv(key), nil
+}
+
+func getEnvInt(key string, fallback int) int {
+	raw := getEnv(key, strconv.Itoa(fallback))
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("Invalid %s, using default %d. Error: %v", key, fallback, err)
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	raw := getEnv(key, strconv.FormatBool(fallback))
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("Invalid %s, using default %t. Error: %v", key, fallback, err)
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration aceita durações no formato do Go ("500ms", "30s", "1h").
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	raw := getEnv(key, fallback.String())
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf("Invalid %s, using default %v. Error: %v", key, fallback, err)
+		return fallback
+	}
+	return value
+}
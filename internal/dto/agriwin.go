@@ -9,6 +9,41 @@ type UserToIntegrate struct {
 	Login      string    `json:"login"`
 	Senha      string    `json:"senha"`
 	Data       time.Time `json:"data"`
+
+	// Filtro dos dados transacionais na Vestro: driver, employee, company ou none.
+	// Vazio usa o modo padrão da configuração; sem valor, filtra pelo login.
+	FiltroVestro      string `json:"filtro_vestro,omitempty"`
+	ValorFiltroVestro string `json:"valor_filtro_vestro,omitempty"`
+
+	// Entidades a buscar para o produtor (ex.: ["supplies", "vehicles"]). Vazio = todas as
+	// selecionadas para a execução; quando preenchido, restringe essa seleção.
+	Entidades []string `json:"entidades,omitempty"`
+}
+
+// TransactionFilter monta o filtro Vestro do produtor, usando defaultMode quando ele não definiu um.
+func (u UserToIntegrate) TransactionFilter(defaultMode FilterMode) (TransactionFilter, error) {
+	mode, err := ParseFilterMode(u.FiltroVestro)
+	if err != nil {
+		return TransactionFilter{}, err
+	}
+	if mode == "" {
+		mode = defaultMode
+	}
+
+	value := u.ValorFiltroVestro
+	if value == "" {
+		value = u.Login
+	}
+	return TransactionFilter{Mode: mode, Value: value}, nil
+}
+
+// Entities combina as entidades do produtor com as selecionadas para a execução.
+func (u UserToIntegrate) Entities(run EntitySet) (EntitySet, error) {
+	own, err := NewEntitySet(u.Entidades)
+	if err != nil {
+		return nil, err
+	}
+	return run.Intersect(own), nil
 }
 
 // IntegrationPayload é o DTO que agrupa todos os dados
@@ -16,6 +51,7 @@ type UserToIntegrate struct {
 type IntegrationPayload struct {
 	ProdutorID   int           `json:"produtor_id"`
 	FetchedAt    time.Time     `json:"fetchedAt"`
+	Batch        *BatchInfo    `json:"batch,omitempty"`
 	Supplies     []Supply      `json:"supplies"`
 	ProductSales []ProductSale `json:"productSales"`
 	Products     []Product     `json:"products"`
@@ -23,9 +59,26 @@ type IntegrationPayload struct {
 	Vehicles     []Vehicle     `json:"vehicles"`
 	Drivers      []Driver      `json:"drivers"`
 	Employees    []Employee    `json:"employees"`
+
+	// EntityStatus traz o resultado da busca de cada entidade: ok, failed, cancelled ou
+	// skipped (deixada de fora de propósito). Vai apenas no envio que leva os dados mestres.
+	EntityStatus map[string]EntityStatus `json:"entityStatus,omitempty"`
+
+	// Changes traz as mudanças de veículos, motoristas e funcionários desde o snapshot
+	// anterior do produtor. Assim como os dados mestres, vai apenas no primeiro envio.
+	Changes []Change `json:"changes,omitempty"`
+
+	// MasterDataChanged indica que as listas de dados mestres do payload mudaram desde a
+	// última entrega e bastam para um envio. Não vai para o Agriwin.
+	MasterDataChanged bool `json:"-"`
 }
 
-// IsEmpty verifica se o payload contém algum dado transacional para ser enviado.
+// IsEmpty verifica se o payload não tem nada a enviar: nem dados transacionais, nem mudanças
+// de registros, nem dados mestres que mudaram. Listas de dados mestres sem mudança conhecida
+// não contam: sozinhas, elas não justificam um envio.
 func (p *IntegrationPayload) IsEmpty() bool {
-	return len(p.Supplies) == 0 && len(p.ProductSales) == 0
+	if p.MasterDataChanged && p.HasMasterData() {
+		return false
+	}
+	return len(p.Supplies) == 0 && len(p.ProductSales) == 0 && len(p.Changes) == 0
 }
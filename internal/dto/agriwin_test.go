@@ -0,0 +1,26 @@
+package dto
+
+import "testing"
+
+func TestIsEmpty(t *testing.T) {
+	products := []Product{{ID: 1, Name: "Diesel S10"}}
+	tests := []struct {
+		name    string
+		payload IntegrationPayload
+		want    bool
+	}{
+		{name: "nothing", payload: IntegrationPayload{}, want: true},
+		{name: "supplies", payload: IntegrationPayload{Supplies: []Supply{{ID: 1}}}},
+		{name: "changes", payload: IntegrationPayload{Changes: []Change{{ID: 1, Kind: ChangeCreated}}}},
+		{name: "master data without a known change", payload: IntegrationPayload{Products: products}, want: true},
+		{name: "changed master data", payload: IntegrationPayload{Products: products, MasterDataChanged: true}},
+		{name: "changed but left out", payload: IntegrationPayload{MasterDataChanged: true}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.IsEmpty(); got != tt.want {
+				t.Errorf("IsEmpty() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// Checkpoint é a marca d'água de um produtor para uma entidade transacional:
+// o registro mais novo que já foi entregue com sucesso ao Agriwin.
+type Checkpoint struct {
+	ProdutorID    int       `json:"produtor_id"`
+	Entity        string    `json:"entity"` // "supplies" ou "productSales"
+	LastTimestamp time.Time `json:"lastTimestamp"`
+	LastID        int       `json:"lastId"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+
+	// SeenIDs guarda os IDs entregues que ainda estão dentro da janela de sobreposição
+	// (ID -> data do registro), para descartar repetidos quando a janela é buscada de novo.
+	SeenIDs map[int]time.Time `json:"seenIds,omitempty"`
+
+	// Hash é o hash do conteúdo entregue, usado nos checkpoints de listas de dados mestres.
+	Hash string `json:"hash,omitempty"`
+}
+
+// Advance move o checkpoint para o registro informado, se ele for mais novo.
+func (c *Checkpoint) Advance(date time.Time, id int) {
+	if date.After(c.LastTimestamp) || (date.Equal(c.LastTimestamp) && id > c.LastID) {
+		c.LastTimestamp = date
+		c.LastID = id
+	}
+}
+
+// Prune descarta os IDs vistos de registros anteriores a horizon.
+func (c *Checkpoint) Prune(horizon time.Time) {
+	for id, date := range c.SeenIDs {
+		if date.Before(horizon) {
+			delete(c.SeenIDs, id)
+		}
+	}
+}
+
+// Merge incorpora outro checkpoint da mesma entidade: a marca d'água mais nova e a união
+// dos IDs vistos.
+func (c *Checkpoint) Merge(other Checkpoint) {
+	c.Advance(other.LastTimestamp, other.LastID)
+	if len(other.SeenIDs) > 0 && c.SeenIDs == nil {
+		c.SeenIDs = make(map[int]time.Time, len(other.SeenIDs))
+	}
+	for id, date := range other.SeenIDs {
+		c.SeenIDs[id] = date
+	}
+}
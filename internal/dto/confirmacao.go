@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// SyncAcknowledgement confirma ao Agriwin qual janela foi importada para um produtor,
+// para que o "data" devolvido em users-to-integrate avance de forma confiável.
+type SyncAcknowledgement struct {
+	RunID        string    `json:"runId"`
+	ProdutorID   int       `json:"produtor_id"`
+	WindowStart  time.Time `json:"windowStart"`
+	WindowEnd    time.Time `json:"windowEnd"`
+	Supplies     EntityAck `json:"supplies"`
+	ProductSales EntityAck `json:"productSales"`
+}
+
+// EntityAck resume o que foi entregue de uma entidade transacional.
+type EntityAck struct {
+	Count         int        `json:"count"`
+	HighestID     int        `json:"highestId"`
+	LastTimestamp *time.Time `json:"lastTimestamp,omitempty"` // Data do registro mais novo entregue
+	Skipped       bool       `json:"skipped,omitempty"`       // A entidade não foi buscada nesta execução
+}
@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+)
+
+// MasterDataKey identifica o último conteúdo entregue de uma lista de dados mestres.
+// A conta Vestro entra na chave para que a troca de login do produtor force um novo envio.
+type MasterDataKey struct {
+	ProdutorID int
+	Account    string // Login do produtor na Vestro
+	Entity     string
+}
+
+// ContentHash calcula o hash do conteúdo de uma lista. Cada registro é serializado e a lista
+// é ordenada antes do hash, para que a ordem devolvida pela Vestro não conte como mudança.
+func ContentHash[T any](list []T) (string, error) {
+	records := make([][]byte, 0, len(list))
+	for _, item := range list {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return "", err
+		}
+		records = append(records, data)
+	}
+	slices.SortFunc(records, bytes.Compare)
+
+	h := sha256.New()
+	for _, data := range records {
+		h.Write(data)
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HasMasterData indica se o payload traz alguma lista de dados mestres. Uma lista vazia
+// conta (a entidade ficou sem registros); uma lista nil foi deixada de fora.
+func (p *IntegrationPayload) HasMasterData() bool {
+	return p.Products != nil || p.FuelTypes != nil || p.Vehicles != nil || p.Drivers != nil || p.Employees != nil
+}
+
+// Omit tira a lista da entidade do payload.
+func (p *IntegrationPayload) Omit(entity string) {
+	switch entity {
+	case EntitySupplies:
+		p.Supplies = nil
+	case EntityProductSales:
+		p.ProductSales = nil
+	case EntityProducts:
+		p.Products = nil
+	case EntityFuelTypes:
+		p.FuelTypes = nil
+	case EntityVehicles:
+		p.Vehicles = nil
+	case EntityDrivers:
+		p.Drivers = nil
+	case EntityEmployees:
+		p.Employees = nil
+	}
+}
@@ -0,0 +1,272 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalScale é o maior número de casas decimais guardado por um Decimal.
+const maxDecimalScale = 9
+
+// Decimal é um número de ponto fixo (valor inteiro + quantidade de casas decimais),
+// usado para volumes, quilometragens e quantidades vindas da Vestro sem passar por float.
+// O valor zero representa um campo ausente e é serializado como null.
+type Decimal struct {
+	unscaled int64
+	scale    uint8
+	valid    bool
+}
+
+// maxDecimalDigits é o tamanho de um int64: números com mais dígitos nunca cabem nele.
+const maxDecimalDigits = 19
+
+// maxDecimalExponent limita o expoente da notação científica. Nenhum valor representável
+// precisa de mais, e o limite evita estouros na conta da escala ("1e-9223372036854775808").
+const maxDecimalExponent = maxDecimalDigits + maxDecimalScale
+
+// ParseDecimal interpreta números nos formatos "1234.5", "1234,5", "1.234,5" e "1,234.5".
+// Quando os dois separadores aparecem, o último é o decimal e o outro separa milhares.
+// Quando só um aparece, ele separa milhares se repetir ("1.234.567") e é o decimal se
+// ocorrer uma única vez, mesmo seguido de três dígitos: a Vestro manda volumes com três
+// casas ("45.320" ou "45,320"), nunca um milhar sem decimais com um único separador.
+// Os grupos de milhar são sempre de três dígitos.
+func ParseDecimal(value string) (Decimal, error) {
+	return parseDecimal(value, true)
+}
+
+// parseDecimal faz a leitura; sem localized, só o ponto é aceito, como decimal (é o caso
+// dos números JSON, que não têm separador de milhar).
+func parseDecimal(value string, localized bool) (Decimal, error) {
+	text := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\u00a0' {
+			return -1
+		}
+		return r
+	}, value)
+	if text == "" {
+		return Decimal{}, nil
+	}
+
+	switch strings.ToLower(strings.TrimLeft(text, "+-")) {
+	case "nan", "inf", "infinity":
+		return Decimal{}, fmt.Errorf("invalid decimal %q: not a finite number", value)
+	}
+
+	mantissa, exponent := text, 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(text[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q: bad exponent", value)
+		}
+		if exp > maxDecimalExponent || exp < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("invalid decimal %q: exponent out of range", value)
+		}
+		mantissa, exponent = text[:i], exp
+	}
+
+	negative := false
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		negative = mantissa[0] == '-'
+		mantissa = mantissa[1:]
+	}
+
+	var intPart, fracPart string
+	if localized {
+		var err error
+		if intPart, fracPart, err = splitDecimalSeparators(mantissa); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q: %w", value, err)
+		}
+	} else {
+		intPart, fracPart, _ = strings.Cut(mantissa, ".")
+	}
+	digits := intPart + fracPart
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+	}
+
+	// Zeros à esquerda não contam; zero com qualquer expoente continua zero.
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return Decimal{valid: true}, nil
+	}
+
+	// O tamanho do resultado é verificado antes de acrescentar zeros, para que um expoente
+	// enorme ("1e300000") seja recusado na hora.
+	scale := len(fracPart) - exponent
+	if scale < 0 && len(digits)-scale > maxDecimalDigits {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: out of range", value)
+	}
+	if scale < 0 {
+		digits += strings.Repeat("0", -scale)
+		scale = 0
+	}
+	// Descarta zeros à direita além da escala máxima; dígitos significativos são erro.
+	for scale > maxDecimalScale {
+		if !strings.HasSuffix(digits, "0") {
+			return Decimal{}, fmt.Errorf("invalid decimal %q: more than %d decimal places", value, maxDecimalScale)
+		}
+		digits = digits[:len(digits)-1]
+		scale--
+	}
+
+	unscaled, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: out of range", value)
+	}
+	if negative {
+		unscaled = -unscaled
+	}
+	return Decimal{unscaled: unscaled, scale: uint8(scale), valid: true}, nil
+}
+
+// splitDecimalSeparators separa parte inteira e fracionária, removendo os separadores de
+// milhar depois de validar os grupos.
+func splitDecimalSeparators(text string) (string, string, error) {
+	lastDot, lastComma := strings.LastIndex(text, "."), strings.LastIndex(text, ",")
+
+	var decimalSep, groupSep string
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimalSep, groupSep = ".", ","
+		if lastComma > lastDot {
+			decimalSep, groupSep = ",", "."
+		}
+		if strings.Count(text, decimalSep) > 1 {
+			return "", "", fmt.Errorf("decimal separator %q appears more than once", decimalSep)
+		}
+	case lastDot >= 0 || lastComma >= 0:
+		sep := "."
+		if lastComma >= 0 {
+			sep = ","
+		}
+		if strings.Count(text, sep) > 1 {
+			groupSep = sep
+			break
+		}
+		decimalSep = sep
+	}
+
+	intPart, fracPart := text, ""
+	if decimalSep != "" {
+		intPart, fracPart, _ = strings.Cut(text, decimalSep)
+	}
+	if groupSep != "" {
+		groups := strings.Split(intPart, groupSep)
+		for i, group := range groups {
+			if !validGroup(group, i == 0) {
+				return "", "", fmt.Errorf("thousands groups must have three digits")
+			}
+		}
+		intPart = strings.Join(groups, "")
+	}
+	return intPart, fracPart, nil
+}
+
+// validGroup indica se group é um grupo de milhar: três dígitos ou, no primeiro grupo,
+// de um a três dígitos sem zero à esquerda.
+func validGroup(group string, first bool) bool {
+	if strings.Trim(group, "0123456789") != "" {
+		return false
+	}
+	if first {
+		return len(group) >= 1 && len(group) <= 3 && group[0] != '0'
+	}
+	return len(group) == 3
+}
+
+// IsValid indica se o campo veio preenchido.
+func (d Decimal) IsValid() bool { return d.valid }
+
+// Sign retorna -1, 0 ou 1 conforme o sinal do número.
+func (d Decimal) Sign() int {
+	switch {
+	case d.unscaled < 0:
+		return -1
+	case d.unscaled > 0:
+		return 1
+	}
+	return 0
+}
+
+// Float64 converte para float, apenas para cálculos aproximados (ex.: logs e métricas).
+func (d Decimal) Float64() float64 {
+	return float64(d.unscaled) / math.Pow10(int(d.scale))
+}
+
+// String formata o número com ponto decimal, preservando as casas recebidas.
+func (d Decimal) String() string {
+	if !d.valid {
+		return ""
+	}
+	digits := strconv.FormatInt(d.unscaled, 10)
+	sign := ""
+	if d.unscaled < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// UnmarshalJSON aceita tanto número quanto string JSON.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+
+	// Números JSON usam sempre o ponto decimal; só strings passam pelos formatos regionais.
+	text, localized := string(data), false
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return fmt.Errorf("invalid decimal %s: %w", data, err)
+		}
+		localized = true
+	}
+
+	parsed, err := parseDecimal(text, localized)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON escreve o número como literal JSON exato (sem arredondamento de float).
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if !d.valid {
+		return []byte("null"), nil
+	}
+	return []byte(d.String()), nil
+}
+
+// errNegativeDecimal é devolvido quando um campo que não admite valores negativos recebe um.
+var errNegativeDecimal = errors.New("negative value not allowed")
+
+// NonNegativeDecimal é um Decimal que rejeita valores negativos na leitura,
+// para campos como volume abastecido e quilometragem.
+type NonNegativeDecimal struct {
+	Decimal
+}
+
+func (d *NonNegativeDecimal) UnmarshalJSON(data []byte) error {
+	var parsed Decimal
+	if err := parsed.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	if parsed.Sign() < 0 {
+		return fmt.Errorf("invalid decimal %s: %w", data, errNegativeDecimal)
+	}
+	d.Decimal = parsed
+	return nil
+}
@@ -0,0 +1,145 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "", want: ""},
+		{in: "0", want: "0"},
+		{in: "1234.5", want: "1234.5"},
+		{in: "1234,5", want: "1234.5"},
+		{in: "-12,50", want: "-12.50"},
+		{in: "+7", want: "7"},
+		{in: "1.234,5", want: "1234.5"},
+		{in: "1,234.5", want: "1234.5"},
+		{in: "1.234.567", want: "1234567"},
+		{in: "1,234,567", want: "1234567"},
+		{in: "1.234.567,89", want: "1234567.89"},
+		{in: "1 234,5", want: "1234.5"},
+		{in: "1\u00a0234,5", want: "1234.5"},
+		{in: "0,123", want: "0.123"},
+		{in: "1234.567", want: "1234.567"},
+		{in: ".5", want: "0.5"},
+		{in: "1.5e3", want: "1500"},
+		{in: "15e-1", want: "1.5"},
+		{in: "1e28", wantErr: true},
+		{in: "1e-28", wantErr: true},
+		{in: "1.000000000000", want: "1.000000000"},
+		{in: "9223372036854775807", want: "9223372036854775807"},
+
+		// Um único separador é sempre o decimal, mesmo com três casas
+		{in: "45.320", want: "45.320"},
+		{in: "12,345", want: "12.345"},
+		{in: "1.234", want: "1.234"},
+		{in: "-123.456", want: "-123.456"},
+
+		// Grupos de milhar inválidos
+		{in: "1.2.3", wantErr: true},
+		{in: "12.34.567", wantErr: true},
+		{in: "1.2345.678", wantErr: true},
+		{in: "1,23.5", wantErr: true},
+		{in: "0.123.456", wantErr: true},
+		{in: "1.234,5,6", wantErr: true},
+
+		{in: "abc", wantErr: true},
+		{in: "1.5.x", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "-Inf", wantErr: true},
+		{in: "1e", wantErr: true},
+		{in: "1.0000000001", wantErr: true},
+		{in: "9223372036854775808", wantErr: true},
+		{in: "1e300000", wantErr: true},
+		{in: "0e300000", wantErr: true},
+		{in: "1e9223372036854775807", wantErr: true},
+		{in: "1e-9223372036854775808", wantErr: true},
+		{in: "1e99999999999999999999", wantErr: true},
+		{in: "1e19", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDecimal(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDecimal(%q) = %s, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDecimal(%q) error: %v", tt.in, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseDecimal(%q) = %q, want %q", tt.in, got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDecimalLargeExponentIsFast(t *testing.T) {
+	start := time.Now()
+	if _, err := ParseDecimal("1e300000"); err == nil {
+		t.Fatal("expected an out of range error")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("ParseDecimal took %v", elapsed)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: `null`, want: `null`},
+		{in: `12.345`, want: `12.345`}, // Número JSON: o ponto é sempre decimal
+		{in: `1e2`, want: `100`},
+		{in: `"12,5"`, want: `12.5`},
+		{in: `"1.234,56"`, want: `1234.56`},
+		{in: `"12.345"`, want: `12.345`},
+		{in: `1e9223372036854775807`, wantErr: true},
+		{in: `1e-9223372036854775808`, wantErr: true},
+		{in: `"x"`, wantErr: true},
+		{in: `0.1`, want: `0.1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var d Decimal
+			err := json.Unmarshal([]byte(tt.in), &d)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %s, want error", tt.in, d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error: %v", tt.in, err)
+			}
+			out, err := json.Marshal(d)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("round trip of %s = %s, want %s", tt.in, out, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonNegativeDecimal(t *testing.T) {
+	var d NonNegativeDecimal
+	if err := json.Unmarshal([]byte(`"-1,5"`), &d); !errors.Is(err, errNegativeDecimal) {
+		t.Fatalf("negative value: got %v, want errNegativeDecimal", err)
+	}
+	if err := json.Unmarshal([]byte(`"1,5"`), &d); err != nil || d.String() != "1.5" {
+		t.Fatalf("positive value: got %s, %v", d, err)
+	}
+}
@@ -0,0 +1,159 @@
+package dto
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Entidades que o job busca na Vestro, com os mesmos nomes usados no payload.
+const (
+	EntitySupplies     = "supplies"
+	EntityProductSales = "productSales"
+	EntityProducts     = "products"
+	EntityFuelTypes    = "fuelTypes"
+	EntityVehicles     = "vehicles"
+	EntityDrivers      = "drivers"
+	EntityEmployees    = "employees"
+)
+
+// AllEntities lista todas as entidades, na ordem em que aparecem no payload.
+var AllEntities = []string{EntitySupplies, EntityProductSales, EntityProducts, EntityFuelTypes, EntityVehicles, EntityDrivers, EntityEmployees}
+
+// EntitySet é um conjunto de entidades selecionadas para a busca. nil significa todas.
+type EntitySet map[string]bool
+
+// ParseEntitySet interpreta uma lista separada por vírgulas ("supplies,vehicles").
+// Vazio ou "all" selecionam todas as entidades (nil).
+func ParseEntitySet(value string) (EntitySet, error) {
+	return NewEntitySet(strings.Split(value, ","))
+}
+
+// NewEntitySet monta o conjunto a partir dos nomes informados, validando cada um.
+func NewEntitySet(names []string) (EntitySet, error) {
+	set := make(EntitySet)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			continue
+		case strings.EqualFold(name, "all"):
+			return nil, nil
+		case !slices.Contains(AllEntities, name):
+			return nil, fmt.Errorf("unknown entity %q (expected %s or all)", name, strings.Join(AllEntities, ", "))
+		}
+		set[name] = true
+	}
+	if len(set) == 0 {
+		return nil, nil
+	}
+	return set, nil
+}
+
+// Has indica se a entidade está selecionada.
+func (s EntitySet) Has(name string) bool {
+	return s == nil || s[name]
+}
+
+// Intersect retorna as entidades presentes nos dois conjuntos.
+func (s EntitySet) Intersect(other EntitySet) EntitySet {
+	if s == nil {
+		return other
+	}
+	if other == nil {
+		return s
+	}
+	result := make(EntitySet)
+	for name := range s {
+		if other[name] {
+			result[name] = true
+		}
+	}
+	return result
+}
+
+func (s EntitySet) String() string {
+	if s == nil {
+		return "all"
+	}
+	var names []string
+	for _, name := range AllEntities {
+		if s[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// FetchPolicy define o que acontece quando a busca de uma lista de dados mestres falha.
+type FetchPolicy string
+
+const (
+	FetchFailFast FetchPolicy = "fail_fast" // Cancela as demais buscas e o produtor falha
+	FetchPartial  FetchPolicy = "partial"   // Envia o que deu certo, com o status de cada entidade no payload
+)
+
+// ParseFetchPolicy valida a política de busca.
+func ParseFetchPolicy(value string) (FetchPolicy, error) {
+	policy := FetchPolicy(strings.ToLower(strings.TrimSpace(value)))
+	switch policy {
+	case FetchFailFast, FetchPartial:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid fetch policy %q (expected fail_fast or partial)", value)
+}
+
+// EntityState é o resultado da busca de uma entidade para o payload.
+type EntityState string
+
+const (
+	EntityOK        EntityState = "ok"
+	EntityFailed    EntityState = "failed"
+	EntityCancelled EntityState = "cancelled" // Interrompida porque outra busca falhou ou o job foi cancelado
+	EntitySkipped   EntityState = "skipped"   // Deixada de fora de propósito (seleção de entidades)
+	EntityUnchanged EntityState = "unchanged" // Deixada de fora porque não mudou desde o último envio
+)
+
+// EntityStatus informa ao Agriwin se a lista de uma entidade no payload está completa.
+// Uma lista vazia só significa "sem registros" com status ok; com failed ou skipped,
+// a entidade simplesmente não foi buscada, e com unchanged o Agriwin já tem a lista atual.
+type EntityStatus struct {
+	Status EntityState `json:"status"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// FailedEntities lista, em ordem alfabética, as entidades cuja busca não foi concluída.
+func (p *IntegrationPayload) FailedEntities() []string {
+	var failed []string
+	for name, status := range p.EntityStatus {
+		if status.Status == EntityFailed || status.Status == EntityCancelled {
+			failed = append(failed, name)
+		}
+	}
+	slices.Sort(failed)
+	return failed
+}
+
+// Count retorna quantos registros da entidade estão no payload.
+func (p *IntegrationPayload) Count(entity string) int {
+	switch entity {
+	case EntitySupplies:
+		return len(p.Supplies)
+	case EntityProductSales:
+		return len(p.ProductSales)
+	case EntityProducts:
+		return len(p.Products)
+	case EntityFuelTypes:
+		return len(p.FuelTypes)
+	case EntityVehicles:
+		return len(p.Vehicles)
+	case EntityDrivers:
+		return len(p.Drivers)
+	case EntityEmployees:
+		return len(p.Employees)
+	}
+	return 0
+}
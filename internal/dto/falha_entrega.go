@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// DeadLetter é um envio ao Agriwin que falhou, guardado em disco para ser reenviado
+// pelo comando replay. O payload é o mesmo que seria enviado, inclusive o BatchInfo.
+type DeadLetter struct {
+	ID            string             `json:"id"`
+	RunID         string             `json:"runId"`
+	ProdutorID    int                `json:"produtor_id"`
+	WindowStart   time.Time          `json:"windowStart"`
+	WindowEnd     time.Time          `json:"windowEnd"`
+	Error         string             `json:"error"`    // Erro da última tentativa
+	Attempts      int                `json:"attempts"` // Tentativas feitas até agora, incluindo a original
+	FailedAt      time.Time          `json:"failedAt"`
+	LastAttemptAt time.Time          `json:"lastAttemptAt"`
+	Payload       IntegrationPayload `json:"payload"`
+
+	// Checkpoints são os checkpoints que o lote teria gravado ao ser aceito. Vão só na última
+	// parte de um lote da importação regular: o replay os grava depois de entregá-la.
+	Checkpoints []Checkpoint `json:"checkpoints,omitempty"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FilterMode define por qual propriedade a Vestro filtra os dados transacionais de um produtor.
+type FilterMode string
+
+const (
+	FilterByDriver   FilterMode = "driver"
+	FilterByEmployee FilterMode = "employee"
+	FilterByCompany  FilterMode = "company"
+	FilterNone       FilterMode = "none"
+)
+
+// ParseFilterMode valida o modo de filtro. Vazio é aceito e significa "usar o padrão".
+func ParseFilterMode(value string) (FilterMode, error) {
+	mode := FilterMode(strings.ToLower(strings.TrimSpace(value)))
+	switch mode {
+	case "", FilterByDriver, FilterByEmployee, FilterByCompany, FilterNone:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid Vestro filter mode %q (expected driver, employee, company or none)", value)
+}
+
+// TransactionFilter é o filtro aplicado em GetSupplies e GetProductSales.
+type TransactionFilter struct {
+	Mode  FilterMode
+	Value string
+}
+
+// Property retorna o nome da propriedade de busca da Vestro ("" quando não há filtro).
+func (f TransactionFilter) Property() string {
+	if f.Mode == FilterNone || f.Value == "" {
+		return ""
+	}
+	return string(f.Mode)
+}
+
+func (f TransactionFilter) String() string {
+	if f.Property() == "" {
+		return "no filter"
+	}
+	return fmt.Sprintf("%s=%s", f.Mode, f.Value)
+}
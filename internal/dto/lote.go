@@ -0,0 +1,64 @@
+package dto
+
+import "encoding/json"
+
+// BatchInfo identifica um envio dentro do conjunto de envios de um produtor em uma execução.
+// Como os dados chegam da Vestro em streaming, cada lote é dividido em partes antes de ser
+// enviado e todas elas levam o total de envios conhecido até o fim desse lote; o total só
+// para de crescer no lote com Last = true. O Agriwin sabe que o conjunto está completo
+// quando recebe o envio com Last = true e todos os envios de 1 até o Total dele.
+type BatchInfo struct {
+	DeliveryID string `json:"deliveryId"` // Mesmo valor em todos os envios do produtor na execução
+	Sequence   int    `json:"sequence"`   // 1, 2, 3...
+	Total      int    `json:"total"`      // Envios até o fim do lote desta parte, >= Sequence
+	Last       bool   `json:"last"`
+}
+
+// Split divide o payload em partes de até maxBytes de JSON (aproximadamente). Os dados
+// mestres vão apenas na primeira parte; um registro nunca é dividido, então uma parte
+// com um único registro (ou só com os dados mestres) pode passar do limite.
+// maxBytes <= 0 devolve o payload inteiro.
+func (p IntegrationPayload) Split(maxBytes int) []IntegrationPayload {
+	if maxBytes <= 0 {
+		return []IntegrationPayload{p}
+	}
+
+	header := IntegrationPayload{ProdutorID: p.ProdutorID, FetchedAt: p.FetchedAt, Batch: p.Batch}
+	emptySize := jsonSize(header)
+
+	current := p
+	current.Supplies, current.ProductSales = nil, nil
+	size := jsonSize(current)
+
+	var chunks []IntegrationPayload
+	// makeRoom fecha a parte atual se o próximo registro (n bytes) não couber nela.
+	makeRoom := func(n int) {
+		if size+n <= maxBytes || size == emptySize {
+			return
+		}
+		chunks = append(chunks, current)
+		current, size = header, emptySize
+	}
+
+	for _, supply := range p.Supplies {
+		n := jsonSize(supply) + 1
+		makeRoom(n)
+		current.Supplies = append(current.Supplies, supply)
+		size += n
+	}
+	for _, sale := range p.ProductSales {
+		n := jsonSize(sale) + 1
+		makeRoom(n)
+		current.ProductSales = append(current.ProductSales, sale)
+		size += n
+	}
+	return append(chunks, current)
+}
+
+func jsonSize(v any) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	fetchedAt := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	batch := &BatchInfo{DeliveryID: "run-7", Sequence: 1}
+	supplies := make([]Supply, 5)
+	for i := range supplies {
+		supplies[i] = Supply{ID: 101 + i, Plate: "ABC1D23"}
+	}
+	sales := []ProductSale{{ID: 201, Plate: "ABC1D23"}, {ID: 202, Plate: "ABC1D23"}}
+
+	header := IntegrationPayload{ProdutorID: 7, FetchedAt: fetchedAt, Batch: batch}
+	record := jsonSize(supplies[0]) + 1
+	twoRecords := jsonSize(header) + 2*record
+
+	// Os abastecimentos têm todos o mesmo tamanho, para a conta de quantos cabem em cada parte
+	withRecords := header
+	withRecords.Supplies = supplies
+
+	mixed := withRecords
+	mixed.ProductSales = sales
+
+	withMaster := withRecords
+	withMaster.Products = []Product{{ID: 1, Name: "Diesel S10", Code: "S10"}}
+
+	onlyMaster := header
+	onlyMaster.Products = withMaster.Products
+
+	tests := []struct {
+		name     string
+		payload  IntegrationPayload
+		maxBytes int
+		want     []int // Registros (abastecimentos + vendas) em cada parte
+	}{
+		{name: "no limit", payload: mixed, maxBytes: 0, want: []int{7}},
+		{name: "fits", payload: mixed, maxBytes: 1 << 20, want: []int{7}},
+		{name: "two records per chunk", payload: withRecords, maxBytes: twoRecords, want: []int{2, 2, 1}},
+		{name: "record larger than the limit", payload: mixed, maxBytes: 1, want: []int{1, 1, 1, 1, 1, 1, 1}},
+		{name: "master data fills the first chunk", payload: withMaster, maxBytes: twoRecords, want: []int{1, 2, 2}},
+		{name: "only master data", payload: onlyMaster, maxBytes: 1, want: []int{0}},
+		{name: "empty", payload: header, maxBytes: twoRecords, want: []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := tt.payload.Split(tt.maxBytes)
+
+			var got []int
+			var gotSupplies []Supply
+			var gotSales []ProductSale
+			for i, chunk := range chunks {
+				got = append(got, len(chunk.Supplies)+len(chunk.ProductSales))
+				gotSupplies = append(gotSupplies, chunk.Supplies...)
+				gotSales = append(gotSales, chunk.ProductSales...)
+
+				if chunk.ProdutorID != 7 || !chunk.FetchedAt.Equal(fetchedAt) || chunk.Batch != batch {
+					t.Errorf("chunk %d lost the payload header", i)
+				}
+				if n := len(chunk.Supplies) + len(chunk.ProductSales); tt.maxBytes > 0 && n > 1 && jsonSize(chunk) > tt.maxBytes {
+					t.Errorf("chunk %d has %d bytes, over the %d limit", i, jsonSize(chunk), tt.maxBytes)
+				}
+				// Os dados mestres vão só na primeira parte
+				if hasMaster := chunk.Products != nil; hasMaster != (i == 0 && tt.payload.Products != nil) {
+					t.Errorf("chunk %d has master data = %t", i, hasMaster)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("records per chunk = %v, want %v", got, tt.want)
+			}
+			if !slices.EqualFunc(gotSupplies, tt.payload.Supplies, func(a, b Supply) bool { return a.ID == b.ID }) ||
+				!slices.EqualFunc(gotSales, tt.payload.ProductSales, func(a, b ProductSale) bool { return a.ID == b.ID }) {
+				t.Errorf("records were lost or reordered")
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package dto
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+)
+
+// ChangeKind é o tipo de mudança de um registro de dados mestres entre dois snapshots.
+type ChangeKind string
+
+const (
+	ChangeCreated     ChangeKind = "created"
+	ChangeUpdated     ChangeKind = "updated"
+	ChangeDeactivated ChangeKind = "deactivated" // Passou de ativo para inativo (com ou sem outras mudanças)
+	ChangeRemoved     ChangeKind = "removed"     // Sumiu da lista da Vestro
+)
+
+// Change é uma mudança em um registro de dados mestres, para o Agriwin manter o histórico.
+// Record traz o registro atual (ou, em removed, o último conhecido); Fields traz os campos
+// alterados em updated e deactivated, com os nomes do JSON.
+type Change struct {
+	Entity string          `json:"entity"`
+	ID     int             `json:"id"`
+	Kind   ChangeKind      `json:"kind"`
+	Fields []FieldChange   `json:"fields,omitempty"`
+	Record json.RawMessage `json:"record"`
+}
+
+// FieldChange é o valor antigo e o novo de um campo alterado.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// Tracked é um registro de dados mestres cujas mudanças são acompanhadas.
+type Tracked interface {
+	RecordID() int
+	Active() bool
+}
+
+func (v Vehicle) RecordID() int  { return v.ID }
+func (v Vehicle) Active() bool   { return v.IsActive }
+func (d Driver) RecordID() int   { return d.ID }
+func (d Driver) Active() bool    { return d.IsActive }
+func (e Employee) RecordID() int { return e.ID }
+func (e Employee) Active() bool  { return e.IsActive }
+
+// Snapshot é a última versão conhecida de uma lista de dados mestres de um produtor,
+// com cada registro guardado como JSON pelo ID.
+type Snapshot struct {
+	ProdutorID int                     `json:"produtor_id"`
+	Entity     string                  `json:"entity"`
+	TakenAt    time.Time               `json:"takenAt"`
+	Records    map[int]json.RawMessage `json:"records"`
+}
+
+// Diff compara a lista atual com o snapshot anterior e devolve as mudanças, ordenadas por
+// ID, junto do novo snapshot.
+func Diff[T Tracked](previous Snapshot, current []T) ([]Change, Snapshot, error) {
+	next := Snapshot{
+		ProdutorID: previous.ProdutorID,
+		Entity:     previous.Entity,
+		TakenAt:    time.Now(),
+		Records:    make(map[int]json.RawMessage, len(current)),
+	}
+
+	var changes []Change
+	for _, record := range current {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, Snapshot{}, err
+		}
+		id := record.RecordID()
+		next.Records[id] = data
+
+		old, ok := previous.Records[id]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Entity: next.Entity, ID: id, Kind: ChangeCreated, Record: data})
+			continue
+		case bytes.Equal(old, data):
+			continue
+		}
+
+		fields, err := diffFields(old, data)
+		if err != nil {
+			return nil, Snapshot{}, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		var before T
+		if err := json.Unmarshal(old, &before); err != nil {
+			return nil, Snapshot{}, err
+		}
+		kind := ChangeUpdated
+		if before.Active() && !record.Active() {
+			kind = ChangeDeactivated
+		}
+		changes = append(changes, Change{Entity: next.Entity, ID: id, Kind: kind, Fields: fields, Record: data})
+	}
+
+	for id, old := range previous.Records {
+		if _, ok := next.Records[id]; !ok {
+			changes = append(changes, Change{Entity: next.Entity, ID: id, Kind: ChangeRemoved, Record: old})
+		}
+	}
+	slices.SortFunc(changes, func(a, b Change) int { return cmp.Compare(a.ID, b.ID) })
+	return changes, next, nil
+}
+
+// diffFields compara dois registros campo a campo, na ordem alfabética dos campos.
+func diffFields(old, current json.RawMessage) ([]FieldChange, error) {
+	var before, after map[string]json.RawMessage
+	if err := json.Unmarshal(old, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(current, &after); err != nil {
+		return nil, err
+	}
+
+	names := slices.Sorted(maps.Keys(after))
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var fields []FieldChange
+	for _, name := range names {
+		if !bytes.Equal(before[name], after[name]) {
+			fields = append(fields, FieldChange{Field: name, Old: before[name], New: after[name]})
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,148 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	truck := Vehicle{ID: 1, Plate: "ABC1D23", Model: "FH 540", IsActive: true}
+	tractor := Vehicle{ID: 2, Plate: "XYZ9K87", Model: "7200J", IsActive: true}
+	pickup := Vehicle{ID: 3, Plate: "QWE4R56", Model: "Hilux", IsActive: false}
+
+	renamed := truck
+	renamed.Plate = "ABC1D24"
+	deactivated := tractor
+	deactivated.IsActive = false
+	deactivated.Model = "7230J"
+	reactivated := pickup
+	reactivated.IsActive = true
+
+	tests := []struct {
+		name     string
+		previous []Vehicle // nil = sem snapshot anterior
+		current  []Vehicle
+		want     []string // "<id> <kind> <campos>"
+	}{
+		{
+			name:    "first snapshot",
+			current: []Vehicle{tractor, truck},
+			want:    []string{"1 created", "2 created"},
+		},
+		{
+			name:     "unchanged",
+			previous: []Vehicle{truck, tractor},
+			current:  []Vehicle{tractor, truck},
+		},
+		{
+			name:     "updated",
+			previous: []Vehicle{truck, tractor},
+			current:  []Vehicle{renamed, tractor},
+			want:     []string{"1 updated plate"},
+		},
+		{
+			name:     "deactivated with other changes",
+			previous: []Vehicle{tractor},
+			current:  []Vehicle{deactivated},
+			want:     []string{"2 deactivated active,model"},
+		},
+		{
+			name:     "reactivated is an update",
+			previous: []Vehicle{pickup},
+			current:  []Vehicle{reactivated},
+			want:     []string{"3 updated active"},
+		},
+		{
+			name:     "removed",
+			previous: []Vehicle{truck, tractor},
+			current:  []Vehicle{truck},
+			want:     []string{"2 removed"},
+		},
+		{
+			name:     "mixed, ordered by id",
+			previous: []Vehicle{pickup, tractor},
+			current:  []Vehicle{deactivated, truck},
+			want:     []string{"1 created", "2 deactivated active,model", "3 removed"},
+		},
+		{
+			name:     "empty list removes everything",
+			previous: []Vehicle{truck},
+			current:  []Vehicle{},
+			want:     []string{"1 removed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := Snapshot{ProdutorID: 7, Entity: EntityVehicles}
+			if tt.previous != nil {
+				_, snap, err := Diff(previous, tt.previous)
+				if err != nil {
+					t.Fatalf("building the previous snapshot: %v", err)
+				}
+				previous = snap
+			}
+
+			changes, next, err := Diff(previous, tt.current)
+			if err != nil {
+				t.Fatalf("Diff error: %v", err)
+			}
+			var got []string
+			for _, change := range changes {
+				if change.Entity != EntityVehicles {
+					t.Errorf("change %d has entity %q", change.ID, change.Entity)
+				}
+				var fields []string
+				for _, field := range change.Fields {
+					fields = append(fields, field.Field)
+				}
+				got = append(got, strings.TrimSpace(fmt.Sprintf("%d %s %s", change.ID, change.Kind, strings.Join(fields, ","))))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+			if next.ProdutorID != 7 || next.Entity != EntityVehicles || len(next.Records) != len(tt.current) {
+				t.Errorf("next snapshot = %d/%s with %d records, want 7/%s with %d", next.ProdutorID, next.Entity, len(next.Records), EntityVehicles, len(tt.current))
+			}
+		})
+	}
+}
+
+func TestDiffRecords(t *testing.T) {
+	before := Driver{ID: 5, Name: "Ana", IsActive: true}
+	after := Driver{ID: 5, Name: "Ana Souza", IsActive: true}
+	_, previous, err := Diff(Snapshot{Entity: EntityDrivers}, []Driver{before})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, _, err := Diff(previous, []Driver{after})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || len(changes[0].Fields) != 1 {
+		t.Fatalf("changes = %+v, want one change with one field", changes)
+	}
+	field := changes[0].Fields[0]
+	if field.Field != "name" || string(field.Old) != `"Ana"` || string(field.New) != `"Ana Souza"` {
+		t.Errorf("field = %s: %s -> %s, want name: \"Ana\" -> \"Ana Souza\"", field.Field, field.Old, field.New)
+	}
+	var record Driver
+	if err := json.Unmarshal(changes[0].Record, &record); err != nil || record != after {
+		t.Errorf("record = %+v (%v), want the current driver", record, err)
+	}
+
+	// Um registro removido traz a última versão conhecida
+	changes, _, err = Diff(previous, []Driver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Kind != ChangeRemoved {
+		t.Fatalf("changes = %+v, want one removal", changes)
+	}
+	if err := json.Unmarshal(changes[0].Record, &record); err != nil || record != before {
+		t.Errorf("removed record = %+v (%v), want the previous driver", record, err)
+	}
+}
@@ -0,0 +1,100 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProducerStatus é o resultado do processamento de um produtor em uma execução do job.
+type ProducerStatus string
+
+const (
+	StatusOK          ProducerStatus = "ok"
+	StatusPartial     ProducerStatus = "partial" // Enviado, mas sem alguma lista de dados mestres ou com registros inválidos
+	StatusNoData      ProducerStatus = "no_data"
+	StatusAuthFailed  ProducerStatus = "auth_failed"
+	StatusFetchFailed ProducerStatus = "fetch_failed"
+	StatusSendFailed  ProducerStatus = "send_failed"
+	StatusNotStarted  ProducerStatus = "not_started" // O job foi interrompido antes de chegar ao produtor
+	StatusInterrupted ProducerStatus = "interrupted" // O job foi cancelado ou estourou o prazo durante o produtor
+	StatusTimedOut    ProducerStatus = "timed_out"   // O produtor estourou o próprio prazo
+)
+
+// Failed indica se o status representa uma falha.
+func (s ProducerStatus) Failed() bool {
+	return s != StatusOK && s != StatusPartial && s != StatusNoData
+}
+
+// ProducerReport resume o que aconteceu com um produtor.
+type ProducerReport struct {
+	ProdutorID   int            `json:"produtor_id"`
+	Status       ProducerStatus `json:"status"`
+	Records      map[string]int `json:"records"` // Registros buscados por entidade (supplies, vehicles...)
+	Batches      int            `json:"batches"`
+	Acked        bool           `json:"acknowledged"`           // Se o Agriwin confirmou o recebimento da janela
+	DeadLettered bool           `json:"deadLettered,omitempty"` // Se o envio que falhou foi guardado para o comando replay
+	DurationMs   int64          `json:"durationMs"`
+	Error        string         `json:"error,omitempty"`
+
+	// InvalidRecords lista os registros transacionais descartados por data ou número inválido.
+	InvalidRecords []InvalidRecord `json:"invalidRecords,omitempty"`
+}
+
+// InvalidRecord é um registro da Vestro que não pôde ser lido e ficou fora do envio.
+type InvalidRecord struct {
+	Entity string `json:"entity"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error"`
+}
+
+// RecordError é o erro de um registro malformado. Nas buscas em streaming ele não encerra a
+// sequência: o registro é pulado e os seguintes continuam chegando.
+type RecordError struct {
+	ID  string // "id" do registro, quando legível
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("invalid record: %v", e.Err)
+	}
+	return fmt.Sprintf("invalid record id=%s: %v", e.ID, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// JobReport é o relatório estruturado de uma execução do job, impresso em JSON pelo main.
+type JobReport struct {
+	RunID       string           `json:"runId"`
+	StartedAt   time.Time        `json:"startedAt"`
+	FinishedAt  time.Time        `json:"finishedAt"`
+	Interrupted string           `json:"interrupted,omitempty"` // Motivo quando o job parou antes de concluir (sinal, prazo...)
+	Producers   []ProducerReport `json:"producers"`
+}
+
+// FailedCount retorna quantos produtores falharam.
+func (r *JobReport) FailedCount() int {
+	failed := 0
+	for _, producer := range r.Producers {
+		if producer.Status.Failed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// AllFailed indica que havia produtores e nenhum deles foi processado com sucesso.
+func (r *JobReport) AllFailed() bool {
+	return len(r.Producers) > 0 && r.FailedCount() == len(r.Producers)
+}
+
+// ReplayReport resume uma execução do comando replay.
+type ReplayReport struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Total      int       `json:"total"`
+	Replayed   int       `json:"replayed"`
+	Remaining  int       `json:"remaining"` // Continuam na fila para a próxima execução
+}
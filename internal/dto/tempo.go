@@ -0,0 +1,101 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VestroTimeLayout é o formato de data usado pela Vestro, com hífens no horário.
+const VestroTimeLayout = "2006-01-02T15-04-05Z"
+
+// vestroLocation é o fuso em que a Vestro grava o horário no formato VestroTimeLayout.
+// Apesar do "Z" no final, algumas instalações gravam a hora local.
+var vestroLocation = time.UTC
+
+// SetVestroLocation define o fuso usado para interpretar e formatar datas no
+// formato da Vestro (ex.: time.UTC ou America/Sao_Paulo). Deve ser chamada na inicialização.
+func SetVestroLocation(loc *time.Location) {
+	if loc != nil {
+		vestroLocation = loc
+	}
+}
+
+// FormatVestroTime formata t no layout e no fuso esperados pelos filtros da Vestro.
+func FormatVestroTime(t time.Time) string {
+	return t.In(vestroLocation).Format(VestroTimeLayout)
+}
+
+// ParseVestroTime interpreta uma data da Vestro. Aceita o layout próprio da Vestro
+// e, como alternativa, RFC3339 (que traz o próprio fuso).
+func ParseVestroTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.ParseInLocation(VestroTimeLayout, value, vestroLocation); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid Vestro date %q: expected %s or RFC3339", value, VestroTimeLayout)
+}
+
+// VestroTime é uma data recebida da Vestro. Na leitura aceita os formatos de ParseVestroTime;
+// na escrita sai em RFC3339 (UTC), para que o Agriwin receba um timestamp de verdade.
+type VestroTime struct {
+	time.Time
+}
+
+func (t *VestroTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid Vestro date %s: %w", data, err)
+	}
+	if raw == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	parsed, err := ParseVestroTime(raw)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t VestroTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.UTC().Format(time.RFC3339))
+}
+
+// TimeRange é uma janela de busca na Vestro. Until zero deixa a janela aberta até agora.
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+func (r TimeRange) String() string {
+	if r.Until.IsZero() {
+		return r.Since.Format(time.RFC3339) + " - now"
+	}
+	return r.Since.Format(time.RFC3339) + " - " + r.Until.Format(time.RFC3339)
+}
+
+// Timestamp é a data do abastecimento.
+func (s Supply) Timestamp() time.Time {
+	return s.Date.Time
+}
+
+// Timestamp é a data da venda.
+func (s ProductSale) Timestamp() time.Time {
+	return s.Date.Time
+}
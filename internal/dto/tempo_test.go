@@ -0,0 +1,111 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// withVestroLocation troca o fuso da Vestro durante o teste.
+func withVestroLocation(t *testing.T, loc *time.Location) {
+	t.Helper()
+	previous := vestroLocation
+	SetVestroLocation(loc)
+	t.Cleanup(func() { vestroLocation = previous })
+}
+
+func TestParseVestroTime(t *testing.T) {
+	saoPaulo := time.FixedZone("BRT", -3*60*60)
+	tests := []struct {
+		name    string
+		loc     *time.Location
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "vestro layout in utc", loc: time.UTC, in: "2026-03-10T08-15-30Z", want: time.Date(2026, 3, 10, 8, 15, 30, 0, time.UTC)},
+		{name: "vestro layout in local time", loc: saoPaulo, in: "2026-03-10T08-15-30Z", want: time.Date(2026, 3, 10, 11, 15, 30, 0, time.UTC)},
+		{name: "surrounding spaces", loc: time.UTC, in: " 2026-03-10T08-15-30Z ", want: time.Date(2026, 3, 10, 8, 15, 30, 0, time.UTC)},
+		{name: "rfc3339 keeps its own zone", loc: saoPaulo, in: "2026-03-10T08:15:30Z", want: time.Date(2026, 3, 10, 8, 15, 30, 0, time.UTC)},
+		{name: "rfc3339 with offset", loc: time.UTC, in: "2026-03-10T08:15:30-03:00", want: time.Date(2026, 3, 10, 11, 15, 30, 0, time.UTC)},
+		{name: "date only", loc: time.UTC, in: "2026-03-10", wantErr: true},
+		{name: "invalid hour", loc: time.UTC, in: "2026-03-10T25-00-00Z", wantErr: true},
+		{name: "garbage", loc: time.UTC, in: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withVestroLocation(t, tt.loc)
+			got, err := ParseVestroTime(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseVestroTime(%q) = %s, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVestroTime(%q) error: %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseVestroTime(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatVestroTime(t *testing.T) {
+	withVestroLocation(t, time.FixedZone("BRT", -3*60*60))
+	at := time.Date(2026, 3, 10, 11, 15, 30, 0, time.UTC)
+	if got, want := FormatVestroTime(at), "2026-03-10T08-15-30Z"; got != want {
+		t.Errorf("FormatVestroTime() = %q, want %q", got, want)
+	}
+	back, err := ParseVestroTime(FormatVestroTime(at))
+	if err != nil || !back.Equal(at) {
+		t.Errorf("round trip = %s (%v), want %s", back, err, at)
+	}
+}
+
+func TestSetVestroLocationIgnoresNil(t *testing.T) {
+	withVestroLocation(t, time.UTC)
+	SetVestroLocation(nil)
+	if vestroLocation != time.UTC {
+		t.Errorf("location = %v, want UTC kept", vestroLocation)
+	}
+}
+
+func TestVestroTimeJSON(t *testing.T) {
+	withVestroLocation(t, time.FixedZone("BRT", -3*60*60))
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: `"2026-03-10T08-15-30Z"`, want: `"2026-03-10T11:15:30Z"`},
+		{in: `"2026-03-10T08:15:30-03:00"`, want: `"2026-03-10T11:15:30Z"`},
+		{in: `null`, want: `null`},
+		{in: `""`, want: `null`},
+		{in: `1741594530`, wantErr: true},
+		{in: `"10/03/2026"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var v VestroTime
+			err := json.Unmarshal([]byte(tt.in), &v)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %s, want error", tt.in, v.Time)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error: %v", tt.in, err)
+			}
+			out, err := json.Marshal(v)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("round trip of %s = %s, want %s", tt.in, out, tt.want)
+			}
+		})
+	}
+}
@@ -4,7 +4,7 @@ package dto
 type VestroResponseWrapper struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data"`
-	Count   int         `json":count"`
+	Count   int         `json:"count"` // Total de registros que atendem à consulta, em todas as páginas
 }
 
 // AuthResponse é a resposta da rota de autenticação.
@@ -15,32 +15,32 @@ type AuthResponse struct {
 
 // Supply é a estrutura de um registro de abastecimento.
 type Supply struct {
-	ID                 int    `json:"id"`
-	Fuel               string `json:"fuel"`
-	Date               string `json:"date"` // "yyyy-mm-ddThh-mm-ssZ"
-	Volume             string `json:"volume"`
-	Plate              string `json:"plate"`
-	Mileage            string `json:"mileage"`
-	Company            string `json:"company"`
-	Employee           string `json:"employee"`
-	Driver             string `json:"driver"`
-	EmployeeEnrollment string `json:"employeeEnrollment"`
-	DriverEnrollment   string `json:"driverEnrollment"`
+	ID                 int                `json:"id"`
+	Fuel               string             `json:"fuel"`
+	Date               VestroTime         `json:"date"` // "yyyy-mm-ddThh-mm-ssZ" na Vestro
+	Volume             NonNegativeDecimal `json:"volume"`
+	Plate              string             `json:"plate"`
+	Mileage            NonNegativeDecimal `json:"mileage"`
+	Company            string             `json:"company"`
+	Employee           string             `json:"employee"`
+	Driver             string             `json:"driver"`
+	EmployeeEnrollment string             `json:"employeeEnrollment"`
+	DriverEnrollment   string             `json:"driverEnrollment"`
 }
 
 // ProductSale representa uma venda de produto consolidado.
 type ProductSale struct {
-	ID                 int    `json:"id"`
-	SerialNumber       string `json:"serialNumber"`
-	Date               string `json:"date"`
-	Name               string `json:"name"`
-	Amount             string `json:"amount"`
-	Driver             string `json:"driver"`
-	DriverEnrollment   string `json:"driverEnrollment"`
-	Plate              string `json:"plate"`
-	Company            string `json:"company"`
-	Employee           string `json:"employee"`
-	EmployeeEnrollment string `json:"employeeEnrollment"`
+	ID                 int                `json:"id"`
+	SerialNumber       string             `json:"serialNumber"`
+	Date               VestroTime         `json:"date"`
+	Name               string             `json:"name"`
+	Amount             NonNegativeDecimal `json:"amount"`
+	Driver             string             `json:"driver"`
+	DriverEnrollment   string             `json:"driverEnrollment"`
+	Plate              string             `json:"plate"`
+	Company            string             `json:"company"`
+	Employee           string             `json:"employee"`
+	EmployeeEnrollment string             `json:"employeeEnrollment"`
 }
 
 // Product representa um produto.
@@ -2,13 +2,33 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Garante America/Sao_Paulo mesmo em imagens sem zoneinfo
+	"vestro/internal/adaptadores/agriwin/autenticacao"
+	"vestro/internal/adaptadores/agriwin/confirmacao"
 	user_provider "vestro/internal/adaptadores/agriwin/usuario"
 	agriwin_api "vestro/internal/adaptadores/agriwin_api"
+	"vestro/internal/adaptadores/checkpoint"
+	"vestro/internal/adaptadores/deadletter"
+	"vestro/internal/adaptadores/dryrun"
+	"vestro/internal/adaptadores/hashes"
+	"vestro/internal/adaptadores/snapshot"
 	vestro_api "vestro/internal/adaptadores/vestro_api"
+	"vestro/internal/aplicacao/agendador"
+	"vestro/internal/aplicacao/portas"
 	servicos "vestro/internal/aplicacao/servicos"
 	"vestro/internal/config"
+	"vestro/internal/dto"
 )
 
 func main() {
@@ -18,27 +38,373 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Verifica se as configurações essenciais estão presentes
-	if cfg.GrailsAppURL == "" {
+	// Comando pedido: "run" (padrão) importa uma vez, "serve" importa no horário agendado até
+	// receber SIGTERM, "replay" reenvia a fila de falhas e "backfill" reimporta um período.
+	// As flags do comando sobrepõem a configuração do ambiente.
+	command, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	var producers, from, to string
+	switch command {
+	case "run":
+		dryRunFlags(flags, cfg)
+		entitiesFlag(flags, cfg)
+	case "serve":
+		dryRunFlags(flags, cfg)
+		entitiesFlag(flags, cfg)
+		flags.StringVar(&cfg.ServeSchedule, "schedule", cfg.ServeSchedule, `cron expression ("0 * * * *", "@hourly"); empty uses --interval`)
+		flags.DurationVar(&cfg.ServeInterval, "interval", cfg.ServeInterval, "time between runs when there is no schedule")
+		flags.DurationVar(&cfg.ServeJitter, "jitter", cfg.ServeJitter, "random delay of up to this much added to each run")
+	case "backfill":
+		dryRunFlags(flags, cfg)
+		entitiesFlag(flags, cfg)
+		flags.StringVar(&producers, "producers", "", "comma-separated producer IDs to backfill")
+		flags.StringVar(&from, "from", "", "first day (2006-01-02) or instant (RFC3339) of the backfill")
+		flags.StringVar(&to, "to", "", "last day (2006-01-02, inclusive) or instant (RFC3339, exclusive) of the backfill")
+	case "replay":
+		// O replay apaga as entradas reenviadas, então nunca roda em dry-run
+		if cfg.DryRun {
+			log.Fatal("The replay command cannot run with DRY_RUN enabled.")
+		}
+	default:
+		log.Fatalf("Unknown command %q (expected run, serve, backfill or replay).", command)
+	}
+	_ = flags.Parse(args)
+
+	// Verifica se as configurações essenciais estão presentes (o dry-run não envia ao Grails)
+	if cfg.GrailsAppURL == "" && !cfg.DryRun {
 		log.Fatal("Essential environment variables (GRAILS_APP_URL) are not set.")
 		os.Exit(1)
 	}
 
+	// Fuso usado para interpretar as datas da Vestro
+	dto.SetVestroLocation(cfg.VestroLocation)
+
 	// --- Composição das Dependências (Dependency Injection) ---
 
 	// 1. Cria os adaptadores (implementações concretas das portas)
-	vestroClient := vestro_api.New(cfg.VestroBaseURL)
-	grailsNotifier := agriwin_api.New(cfg.GrailsAppURL)
-	agriwinUserProvider := user_provider.New(cfg.AgriwinUsersURL)
+	vestroClient := vestro_api.New(cfg.VestroBaseURL, vestro_api.Options{
+		Retry: vestro_api.RetryPolicy{
+			MaxAttempts: cfg.VestroRetryMaxAttempts,
+			BaseDelay:   cfg.VestroRetryBaseDelay,
+			MaxDelay:    cfg.VestroRetryMaxDelay,
+			TotalBudget: cfg.VestroRetryBudget,
+		},
+		MaxInFlight:     cfg.VestroMaxInFlight,
+		PageConcurrency: cfg.VestroPageConcurrency,
+		TokenTTL:        cfg.VestroTokenTTL,
+		RefreshMargin:   cfg.VestroRefreshMargin,
+		RefreshPath:     cfg.VestroRefreshPath,
+		EndDateParam:    cfg.VestroEndDateParam,
+	})
+	// Todas as chamadas ao Agriwin usam a mesma autenticação (e o mesmo token OAuth2)
+	agriwinAuth, err := autenticacao.New(autenticacao.Options{
+		Mode:         cfg.AgriwinAuthMode,
+		Token:        cfg.AgriwinAuthToken,
+		TokenURL:     cfg.AgriwinOAuthTokenURL,
+		ClientID:     cfg.AgriwinOAuthClientID,
+		ClientSecret: cfg.AgriwinOAuthClientSecret,
+		Scope:        cfg.AgriwinOAuthScope,
+		HMACSecret:   cfg.AgriwinHMACSecret,
+		HMACKeyID:    cfg.AgriwinHMACKeyID,
+	})
+	if err != nil {
+		log.Fatalf("Invalid Agriwin authentication: %v", err)
+	}
+	var notifier portas.Notifier = agriwin_api.New(cfg.GrailsAppURL, agriwinAuth)
+	agriwinUserProvider := user_provider.New(cfg.AgriwinUsersURL, agriwinAuth)
+	// A confirmação da janela importada é opcional (AGRIWIN_ACK_URL vazio desativa)
+	var syncAcknowledger portas.SyncAcknowledger
+	if cfg.AgriwinAckURL != "" {
+		syncAcknowledger = confirmacao.New(cfg.AgriwinAckURL, agriwinAuth)
+	}
+	checkpoints, err := checkpoint.Open(cfg.CheckpointStore, cfg.CheckpointPath)
+	if err != nil {
+		log.Fatalf("Failed to open checkpoint store: %v", err)
+	}
+	// A fila de envios que falharam também é opcional (DEAD_LETTER_DIR vazio desativa)
+	var deadLetters portas.DeadLetterQueue
+	if cfg.DeadLetterDir != "" {
+		queue, err := deadletter.New(cfg.DeadLetterDir)
+		if err != nil {
+			log.Fatalf("Failed to open dead-letter queue: %v", err)
+		}
+		deadLetters = queue
+	}
+	// Os snapshots para detectar mudanças de veículos, motoristas e funcionários também são
+	// opcionais (SNAPSHOT_DIR vazio desativa)
+	var snapshots portas.SnapshotStore
+	if cfg.SnapshotDir != "" {
+		store, err := snapshot.New(cfg.SnapshotDir)
+		if err != nil {
+			log.Fatalf("Failed to open snapshot store: %v", err)
+		}
+		snapshots = store
+	}
+
+	// No dry-run os payloads são gravados em vez de enviados, e nada que indique uma entrega
+	// acontece: a janela não é confirmada, checkpoints e snapshots não avançam e não há fila
+	// de reenvio.
+	if cfg.DryRun {
+		log.Printf("Dry-run: payloads will be written to %q as %s instead of being sent to Agriwin.", cfg.DryRunOutput, cfg.DryRunFormat)
+		dryRunNotifier, err := dryrun.New(cfg.DryRunOutput, cfg.DryRunFormat)
+		if err != nil {
+			log.Fatalf("Failed to set up dry-run: %v", err)
+		}
+		notifier = dryRunNotifier
+		// Com "-", a saída padrão fica só com os payloads; o relatório vai para a saída de
+		// erro, junto dos logs
+		if cfg.DryRunOutput == "-" {
+			reportOutput = os.Stderr
+		}
+		syncAcknowledger = nil
+		checkpoints = checkpoint.ReadOnly(checkpoints)
+		snapshots = snapshot.ReadOnly(snapshots)
+		deadLetters = nil
+	}
+
+	// Hashes dos dados mestres já entregues: o modo serve os guarda em memória; as execuções
+	// avulsas, no store de checkpoints. No dry-run o store é somente leitura, então o serve
+	// também o usa para não dar as listas gravadas como entregues.
+	masterHashes := hashes.NewCheckpointStore(checkpoints)
+	if command == "serve" && !cfg.DryRun {
+		masterHashes = hashes.NewMemoryStore()
+	}
 
 	// 2. Cria o serviço do core, injetando os adaptadores como interfaces
-	importerService := servicos.New(vestroClient, grailsNotifier, agriwinUserProvider, cfg.FetchDataSince)
+	importerService := servicos.New(vestroClient, notifier, agriwinUserProvider, syncAcknowledger, checkpoints, deadLetters, masterHashes, snapshots, servicos.Options{
+		FetchSince:          cfg.FetchDataSince,
+		FetchOverlap:        cfg.FetchOverlap,
+		BatchSize:           cfg.ForwardBatchSize,
+		MaxChunkBytes:       cfg.ForwardMaxBytes,
+		DefaultFilterMode:   cfg.DefaultFilterMode,
+		FetchPolicy:         cfg.FetchPolicy,
+		Entities:            cfg.Entities,
+		ProducerConcurrency: cfg.ProducerConcurrency,
+		JobTimeout:          cfg.JobTimeout,
+		ProducerTimeout:     cfg.ProducerTimeout,
+	})
+
+	// 3. Executa o comando pedido. SIGTERM/SIGINT cancelam o contexto: as buscas em andamento
+	// são interrompidas e o relatório indica os produtores que ficaram para trás.
+	ctx := shutdownContext()
+	var code int
+	switch command {
+	case "run":
+		code = runImport(ctx, importerService)
+	case "serve":
+		code = serve(ctx, importerService, vestroClient.ResetRetryBudget, agendador.Options{
+			Schedule: cfg.ServeSchedule,
+			Interval: cfg.ServeInterval,
+			Jitter:   cfg.ServeJitter,
+		})
+	case "replay":
+		if deadLetters == nil {
+			log.Fatal("The replay command needs DEAD_LETTER_DIR to be set.")
+		}
+		replayService := servicos.NewReplayService(notifier, deadLetters, checkpoints, servicos.ReplayOptions{
+			MaxAttempts:  cfg.ReplayMaxAttempts,
+			BaseDelay:    cfg.ReplayBaseDelay,
+			MaxDelay:     cfg.ReplayMaxDelay,
+			FetchOverlap: cfg.FetchOverlap,
+		})
+		code = runReplay(ctx, replayService)
+	case "backfill":
+		req, err := backfillRequest(producers, from, to, cfg.VestroLocation)
+		if err != nil {
+			log.Fatalf("Invalid backfill: %v", err)
+		}
+		code = runBackfill(ctx, importerService, req)
+	}
+
+	// os.Exit não executa defers, então o store é fechado explicitamente
+	if checkpoints != nil {
+		if closeErr := checkpoints.Close(); closeErr != nil {
+			log.Printf("Failed to close checkpoint store: %v", closeErr)
+		}
+	}
+	os.Exit(code)
+}
+
+// runImport executa a importação, imprime o relatório e devolve o código de saída.
+func runImport(ctx context.Context, importerService *servicos.ImporterService) int {
+	report, err := importerService.RunImport(ctx, nil)
+	if err != nil {
+		log.Printf("Job execution failed: %v", err)
+		return exitJobError // Em um job, é importante sair com um código de erro
+	}
+
+	// Imprime o relatório e sai com um código que o agendador consiga interpretar
+	printReport(report)
+	return exitCode(report)
+}
+
+// serve roda a importação no horário agendado até ctx ser cancelado (SIGTERM ou SIGINT). O processo
+// continua vivo entre as execuções, então tokens da Vestro e demais caches são reaproveitados;
+// o orçamento de retries da Vestro, não: resetRetries o zera antes de cada execução.
+// No desligamento, os produtores em andamento terminam antes de o processo sair.
+func serve(ctx context.Context, importerService *servicos.ImporterService, resetRetries func(), opts agendador.Options) int {
+	scheduler, err := agendador.New(opts, func(ctx context.Context, stop <-chan struct{}) {
+		resetRetries()
+		report, err := importerService.RunImport(ctx, stop)
+		if err != nil {
+			log.Printf("Import run failed: %v", err)
+			return
+		}
+		printReport(report)
+	})
+	if err != nil {
+		log.Printf("Invalid serve schedule: %v", err)
+		return exitJobError
+	}
+
+	log.Println("Serving scheduled imports...")
+	scheduler.Run(ctx)
+	return exitOK
+}
+
+// runBackfill reimporta o período pedido, imprime o relatório e devolve o código de saída.
+func runBackfill(ctx context.Context, importerService *servicos.ImporterService, req servicos.BackfillRequest) int {
+	report, err := importerService.RunBackfill(ctx, req)
+	if err != nil {
+		log.Printf("Backfill failed: %v", err)
+		return exitJobError
+	}
+	printReport(report)
+	return exitCode(report)
+}
+
+// runReplay reenvia a fila de envios que falharam e devolve o código de saída.
+func runReplay(ctx context.Context, replayService *servicos.ReplayService) int {
+	report, err := replayService.Replay(ctx)
+	if report != nil {
+		printReport(report)
+	}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		log.Printf("Replay interrupted: %v", context.Cause(ctx))
+		return exitInterrupted
+	case err != nil:
+		log.Printf("Replay failed: %v", err)
+		return exitJobError
+	case report.Remaining == 0:
+		return exitOK
+	case report.Replayed > 0:
+		return exitPartialFailure
+	}
+	return exitTotalFailure
+}
+
+// shutdownContext é cancelado no primeiro SIGTERM ou SIGINT, com o sinal como causa.
+func shutdownContext() context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %s, shutting down...", sig)
+		cancel(fmt.Errorf("received signal %s", sig))
+	}()
+	return ctx
+}
+
+// reportOutput recebe o relatório da execução: a saída padrão, a não ser que ela esteja com
+// os payloads do dry-run.
+var reportOutput io.Writer = os.Stdout
 
-	// 3. Executa o serviço
-	if err := importerService.RunImport(context.Background()); err != nil {
-		log.Fatalf("Job execution failed: %v", err)
-		os.Exit(1) // Em um job, é importante sair com um código de erro
+func printReport(report any) {
+	if err := json.NewEncoder(reportOutput).Encode(report); err != nil {
+		log.Printf("Failed to print job report: %v", err)
 	}
+}
+
+// Códigos de saída do job, para que o agendador possa alertar.
+const (
+	exitOK             = 0
+	exitJobError       = 1 // O job nem chegou a processar produtores
+	exitPartialFailure = 2 // Parte dos produtores falhou
+	exitTotalFailure   = 3 // Todos os produtores falharam
+	exitInterrupted    = 4 // O job foi cancelado (sinal ou prazo) antes de concluir
+)
 
+func exitCode(report *dto.JobReport) int {
+	switch {
+	case report.Interrupted != "":
+		log.Printf("Job interrupted (%s) with %d of %d producer(s) not completed.", report.Interrupted, report.FailedCount(), len(report.Producers))
+		return exitInterrupted
+	case report.AllFailed():
+		log.Printf("Job finished with all %d producer(s) failing.", len(report.Producers))
+		return exitTotalFailure
+	case report.FailedCount() > 0:
+		log.Printf("Job finished with %d of %d producer(s) failing.", report.FailedCount(), len(report.Producers))
+		return exitPartialFailure
+	}
 	log.Println("Job completed successfully.")
+	return exitOK
+}
+
+// dryRunFlags registra as flags do dry-run nos comandos que enviam ao Agriwin.
+func dryRunFlags(flags *flag.FlagSet, cfg *config.Config) {
+	flags.BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "write the payloads instead of sending them to Agriwin")
+	flags.StringVar(&cfg.DryRunOutput, "dry-run-output", cfg.DryRunOutput, `directory for the dry-run payloads, or "-" for stdout`)
+	flags.StringVar(&cfg.DryRunFormat, "dry-run-format", cfg.DryRunFormat, "dry-run format: json or ndjson")
+}
+
+// entitiesFlag registra a flag que escolhe as entidades buscadas na execução.
+func entitiesFlag(flags *flag.FlagSet, cfg *config.Config) {
+	flags.Func("entities", "comma-separated entities to fetch (default all): "+strings.Join(dto.AllEntities, ","), func(value string) error {
+		entities, err := dto.ParseEntitySet(value)
+		if err != nil {
+			return err
+		}
+		cfg.Entities = entities
+		return nil
+	})
+}
+
+// backfillRequest interpreta as flags do backfill. Datas sem horário são dias no fuso da
+// Vestro, e o dia de --to é incluído inteiro.
+func backfillRequest(producers, from, to string, loc *time.Location) (servicos.BackfillRequest, error) {
+	var req servicos.BackfillRequest
+	for _, field := range strings.Split(producers, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return req, fmt.Errorf("invalid producer ID %q", field)
+		}
+		req.ProdutorIDs = append(req.ProdutorIDs, id)
+	}
+	if len(req.ProdutorIDs) == 0 {
+		return req, fmt.Errorf("--producers is required")
+	}
+
+	var err error
+	if req.From, err = parseBackfillTime(from, loc, false); err != nil {
+		return req, fmt.Errorf("--from: %w", err)
+	}
+	if req.To, err = parseBackfillTime(to, loc, true); err != nil {
+		return req, fmt.Errorf("--to: %w", err)
+	}
+	return req, nil
+}
+
+func parseBackfillTime(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("a date is required")
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: expected 2006-01-02 or RFC3339", value)
+	}
+	return t.In(loc), nil
 }